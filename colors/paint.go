@@ -0,0 +1,153 @@
+// Copyright 2015 Brent Rowland.
+// Use of this source code is governed the Apache License, Version 2.0, as described in the LICENSE file.
+
+package colors
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var errInvalidPaint = errors.New("Invalid paint.")
+
+// Paint is a color in any color model: an RGB Color, a GrayLevel, a CMYK or a Spot.
+// Writers unable to paint in a model paint the nearest RGB color instead.
+// Paints are compared with ==, so implementations must be comparable.
+type Paint interface {
+	RGB64() (r, g, b float64)
+}
+
+// GrayLevel is a shade from 0 for black to 1 for white.
+type GrayLevel float64
+
+func (this GrayLevel) RGB64() (r, g, b float64) {
+	return float64(this), float64(this), float64(this)
+}
+
+// CMYK is a color mixed from cyan, magenta, yellow and black inks, each from 0 for none to 1 for full coverage.
+type CMYK struct {
+	C, M, Y, K float64
+}
+
+func (this CMYK) RGB64() (r, g, b float64) {
+	return (1 - this.C) * (1 - this.K), (1 - this.M) * (1 - this.K), (1 - this.Y) * (1 - this.K)
+}
+
+// Spot is a tint, from 0 for none to 1 for full strength, of a named colorant such as a Pantone ink,
+// printed on a separation of its own. Devices without the colorant paint Alternate, its full-strength equivalent
+// as a Color, GrayLevel or CMYK, scaled by Tint instead.
+type Spot struct {
+	Name      string
+	Alternate Paint
+	Tint      float64
+}
+
+func (this Spot) RGB64() (r, g, b float64) {
+	r, g, b = this.Alternate.RGB64()
+	return 1 - this.Tint*(1-r), 1 - this.Tint*(1-g), 1 - this.Tint*(1-b)
+}
+
+// ToRGB returns the RGB Color nearest to p.
+func ToRGB(p Paint) Color {
+	if c, ok := p.(Color); ok {
+		return c
+	}
+	r, g, b := p.RGB64()
+	component := func(v float64) Color {
+		return Color(math.Max(0, math.Min(255, math.Floor(v*255+0.5))))
+	}
+	return component(r)<<16 | component(g)<<8 | component(b)
+}
+
+// ParsePaint returns the paint described by s: a color name or hexadecimal RGB color as for NamedColor,
+// gray(g), cmyk(c, m, y, k) or spot(name, alternate, tint), with components given as fractions or percentages
+// from 0 to 1 or 0% to 100%.
+// The tint of a spot color defaults to 1.
+func ParsePaint(s string) (Paint, error) {
+	s = strings.TrimSpace(s)
+	open := strings.Index(s, "(")
+	if open < 0 {
+		return NamedColor(s)
+	}
+	if !strings.HasSuffix(s, ")") {
+		return nil, errInvalidPaint
+	}
+	args := splitArgs(s[open+1 : len(s)-1])
+	switch strings.ToLower(strings.TrimSpace(s[:open])) {
+	case "gray", "grey":
+		if len(args) == 1 {
+			if v, ok := parseComponent(args[0]); ok {
+				return GrayLevel(v), nil
+			}
+		}
+	case "cmyk":
+		if len(args) == 4 {
+			var v [4]float64
+			for i, arg := range args {
+				var ok bool
+				if v[i], ok = parseComponent(arg); !ok {
+					return nil, errInvalidPaint
+				}
+			}
+			return CMYK{v[0], v[1], v[2], v[3]}, nil
+		}
+	case "spot":
+		if len(args) == 2 || len(args) == 3 {
+			alternate, err := ParsePaint(args[1])
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := alternate.(Spot); ok {
+				return nil, errInvalidPaint
+			}
+			spot := Spot{Name: args[0], Alternate: alternate, Tint: 1}
+			if len(args) == 3 {
+				var ok bool
+				if spot.Tint, ok = parseComponent(args[2]); !ok {
+					return nil, errInvalidPaint
+				}
+			}
+			return spot, nil
+		}
+	}
+	return nil, errInvalidPaint
+}
+
+// splitArgs splits s at commas outside parentheses, trimming spaces from each argument.
+func splitArgs(s string) []string {
+	var args []string
+	depth, start := 0, 0
+	for i, c := range s {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				args = append(args, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(args, strings.TrimSpace(s[start:]))
+}
+
+// parseComponent parses a fraction, or a percentage if followed by %, failing if it lies outside 0 to 1.
+func parseComponent(s string) (float64, bool) {
+	scale := 1.0
+	if strings.HasSuffix(s, "%") {
+		s, scale = strings.TrimSuffix(s, "%"), 100
+	}
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0, false
+	}
+	v /= scale
+	if !(v >= 0 && v <= 1) {
+		return 0, false
+	}
+	return v, true
+}
@@ -0,0 +1,58 @@
+// Copyright 2015 Brent Rowland.
+// Use of this source code is governed the Apache License, Version 2.0, as described in the LICENSE file.
+
+package colors
+
+import (
+	"testing"
+)
+
+func TestPaint_RGB64(t *testing.T) {
+	r, g, b := GrayLevel(0.5).RGB64()
+	expectF(t, 0.5, r)
+	expectF(t, 0.5, g)
+	expectF(t, 0.5, b)
+
+	r, g, b = CMYK{0, 0.5, 1, 0.5}.RGB64()
+	expectF(t, 0.5, r)
+	expectF(t, 0.25, g)
+	expectF(t, 0, b)
+
+	r, g, b = Spot{Name: "Reflex Blue", Alternate: Color(0x0000FF), Tint: 0.5}.RGB64()
+	expectF(t, 0.5, r)
+	expectF(t, 0.5, g)
+	expectF(t, 1, b)
+}
+
+func TestToRGB(t *testing.T) {
+	check(t, ToRGB(Red) == Red, "Color should be unchanged.")
+	check(t, ToRGB(GrayLevel(1)) == White, "Gray 1 should be white.")
+	check(t, ToRGB(CMYK{1, 0, 1, 0}) == Lime, "Cyan and yellow should make lime.")
+}
+
+func TestParsePaint(t *testing.T) {
+	p, err := ParsePaint("AliceBlue")
+	check(t, err == nil && p == AliceBlue, "Expecting AliceBlue.")
+
+	p, err = ParsePaint("gray(25%)")
+	check(t, err == nil && p == GrayLevel(0.25), "Expecting gray.")
+
+	p, err = ParsePaint("cmyk(0, 0.91, 76%, 0)")
+	check(t, err == nil && p == CMYK{0, 0.91, 0.76, 0}, "Expecting CMYK.")
+
+	p, err = ParsePaint("spot(PANTONE 185 C, cmyk(0, 0.91, 0.76, 0), 0.5)")
+	check(t, err == nil, "Error parsing spot.")
+	check(t, p == Spot{Name: "PANTONE 185 C", Alternate: CMYK{0, 0.91, 0.76, 0}, Tint: 0.5}, "Expecting spot.")
+
+	p, err = ParsePaint("spot(Gold, gray(0.8))")
+	check(t, err == nil && p.(Spot).Tint == 1, "Spot tint should default to 1.")
+
+	p, err = ParsePaint("cmyk(0%, 0, 100%, 1)")
+	check(t, err == nil && p == CMYK{0, 0, 1, 1}, "Components of 0 and 1 should be accepted.")
+
+	for _, s := range []string{"cmyk(0, 0, 0)", "gray(x)", "spot(A, spot(B, red))", "gray(0.5",
+		"gray(150%)", "gray(-0.1)", "gray(NaN)", "cmyk(2, 0, 0, 0)", "spot(Gold, gray(0.8), 1.5)", "spot(Gold, gray(2))"} {
+		_, err = ParsePaint(s)
+		check(t, err == errInvalidPaint, "Expecting error parsing "+s)
+	}
+}
@@ -0,0 +1,10 @@
+// Copyright 2015 Brent Rowland.
+// Use of this source code is governed the Apache License, Version 2.0, as described in the LICENSE file.
+
+package colors
+
+// Stop is a color at an offset, from 0 at the start to 1 at the end, along a gradient.
+type Stop struct {
+	Offset float64
+	Color  Color
+}
@@ -3,7 +3,12 @@
 
 package font
 
-import "github.com/rowland/leadtype/options"
+import (
+	"fmt"
+	"io"
+
+	"github.com/rowland/leadtype/options"
+)
 
 type Font struct {
 	family       string
@@ -65,6 +70,14 @@ func (font *Font) Descent() int {
 	return font.metrics.Descent()
 }
 
+// Embeddable reports whether the font program is available and licensed for embedding.
+func (font *Font) Embeddable() bool {
+	if ff, ok := font.metrics.(FontFile); ok {
+		return ff.Embeddable()
+	}
+	return false
+}
+
 func (font *Font) Family() string {
 	return font.metrics.Family()
 }
@@ -81,6 +94,14 @@ func (font *Font) FullName() string {
 	return font.metrics.FullName()
 }
 
+// GlyphIndex returns the index of the glyph for codepoint within the font program, or -1 if unknown.
+func (font *Font) GlyphIndex(codepoint rune) int {
+	if ff, ok := font.metrics.(FontFile); ok {
+		return ff.GlyphIndex(codepoint)
+	}
+	return -1
+}
+
 func (font *Font) HasRune(rune rune) bool {
 	if font.RuneSet == nil {
 		_, err := font.metrics.AdvanceWidth(rune)
@@ -147,6 +168,14 @@ func (font *Font) Style() string {
 	return font.style
 }
 
+// Subset writes a copy of the font program to wr, containing only the specified glyphs.
+func (font *Font) Subset(wr io.Writer, glyphs []int) error {
+	if ff, ok := font.metrics.(FontFile); ok {
+		return ff.Subset(wr, glyphs)
+	}
+	return fmt.Errorf("Font %s cannot be subset.", font.PostScriptName())
+}
+
 func (font *Font) SubType() string {
 	return font.subType
 }
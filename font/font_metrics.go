@@ -3,6 +3,8 @@
 
 package font
 
+import "io"
+
 type FontMetrics interface {
 	AdvanceWidth(codepoint rune) (width int, err bool)
 	Ascent() int
@@ -38,3 +40,10 @@ type FontMetrics interface {
 	Version() string
 	XHeight() int
 }
+
+// FontFile is implemented by FontMetrics backed by a font program that may be embedded in a document.
+type FontFile interface {
+	Embeddable() bool
+	GlyphIndex(codepoint rune) int
+	Subset(wr io.Writer, glyphs []int) error
+}
@@ -71,7 +71,7 @@ func (dw *DocWriter) CurPage() *PageWriter {
 	return dw.curPage
 }
 
-func (dw *DocWriter) FontColor() colors.Color {
+func (dw *DocWriter) FontColor() colors.Paint {
 	return dw.CurPage().FontColor()
 }
 
@@ -180,7 +180,7 @@ func (dw *DocWriter) LineCapStyle() LineCapStyle {
 	return dw.CurPage().LineCapStyle()
 }
 
-func (dw *DocWriter) LineColor() colors.Color {
+func (dw *DocWriter) LineColor() colors.Paint {
 	return dw.CurPage().LineColor()
 }
 
@@ -290,7 +290,7 @@ func (dw *DocWriter) ResetFonts() {
 	dw.CurPage().ResetFonts()
 }
 
-func (dw *DocWriter) SetFillColor(color interface{}) (prev colors.Color) {
+func (dw *DocWriter) SetFillColor(color interface{}) (prev colors.Paint) {
 	return dw.CurPage().SetFillColor(color)
 }
 
@@ -298,7 +298,7 @@ func (dw *DocWriter) SetFont(name string, size float64, options options.Options)
 	return dw.CurPage().SetFont(name, size, options)
 }
 
-func (dw *DocWriter) SetFontColor(color interface{}) (lastColor colors.Color) {
+func (dw *DocWriter) SetFontColor(color interface{}) (lastColor colors.Paint) {
 	return dw.CurPage().SetFontColor(color)
 }
 
@@ -310,7 +310,7 @@ func (dw *DocWriter) SetFontStyle(style string) (prev string, err error) {
 	return dw.CurPage().SetFontStyle(style)
 }
 
-func (dw *DocWriter) SetLineColor(color colors.Color) (prev colors.Color) {
+func (dw *DocWriter) SetLineColor(color colors.Paint) (prev colors.Paint) {
 	return dw.CurPage().SetLineColor(color)
 }
 
@@ -368,7 +368,7 @@ func (pw *PageWriter) flushText() {
 	pw.flushing = false
 }
 
-func (pw *PageWriter) FontColor() colors.Color {
+func (pw *PageWriter) FontColor() colors.Paint {
 	return pw.fontColor
 }
 
@@ -391,7 +391,7 @@ func (pw *PageWriter) LineCapStyle() LineCapStyle {
 	return pw.lineCapStyle
 }
 
-func (pw *PageWriter) LineColor() colors.Color {
+func (pw *PageWriter) LineColor() colors.Paint {
 	return pw.lineColor
 }
 
@@ -690,7 +690,8 @@ func (pw *PageWriter) SetFont(name string, size float64, options options.Options
 	return pw.AddFont(name, options)
 }
 
-func (pw *PageWriter) SetFillColor(value interface{}) (prev colors.Color) {
+// SetFillColor sets the colour with which to fill shapes. Paints other than colors.Color are filled in the nearest RGB colour.
+func (pw *PageWriter) SetFillColor(value interface{}) (prev colors.Paint) {
 	prev = pw.fillColor
 
 	switch value := value.(type) {
@@ -704,12 +705,14 @@ func (pw *PageWriter) SetFillColor(value interface{}) (prev colors.Color) {
 		pw.fillColor = colors.Color(value)
 	case colors.Color:
 		pw.fillColor = value
+	case colors.Paint:
+		pw.fillColor = colors.ToRGB(value)
 	}
 
 	return
 }
 
-func (pw *PageWriter) SetFontColor(value interface{}) (prev colors.Color) {
+func (pw *PageWriter) SetFontColor(value interface{}) (prev colors.Paint) {
 	prev = pw.fontColor
 
 	switch value := value.(type) {
@@ -722,6 +725,8 @@ func (pw *PageWriter) SetFontColor(value interface{}) (prev colors.Color) {
 		pw.fontColor = colors.Color(value)
 	case colors.Color:
 		pw.fontColor = value
+	case colors.Paint:
+		pw.fontColor = colors.ToRGB(value)
 	}
 
 	return
@@ -764,9 +769,9 @@ func (pw *PageWriter) SetLineCapStyle(lineCapStyle LineCapStyle) (prev LineCapSt
 	return
 }
 
-func (pw *PageWriter) SetLineColor(value colors.Color) (prev colors.Color) {
+func (pw *PageWriter) SetLineColor(value colors.Paint) (prev colors.Paint) {
 	prev = pw.lineColor
-	pw.lineColor = value
+	pw.lineColor = colors.ToRGB(value)
 	return
 }
 
@@ -5,18 +5,70 @@ package ltml
 
 import (
 	"fmt"
+	"math"
+	"strconv"
+	"strings"
 
 	"github.com/rowland/leadtype/colors"
 )
 
+// BrushStyle fills areas with a solid color or, if its type is linear or radial, a gradient through its stops.
+// The angle of a linear gradient is measured in degrees counterclockwise from left to right.
+// A pattern other than solid fills with hatching in the brush's color, such as the diagonal lines
+// or dots of the pdf package's Hatches, spacing apart and width thick.
 type BrushStyle struct {
-	id    string
-	color colors.Color
+	id      string
+	color   colors.Paint
+	opacity *float64
+	kind    string
+	stops   []colors.Stop
+	angle   float64
+	pattern string
+	spacing float64
+	width   float64
 }
 
+const (
+	defaultBrushPattern = "solid"
+	defaultHatchSpacing = 6
+	defaultHatchWidth   = 1
+)
+
 func (bs *BrushStyle) Apply(w Writer) {
 	// fmt.Printf("Applying %s\n", bs)
-	w.SetFillColor(bs.color)
+	w.SetFillColor(paintOrBlack(bs.color))
+	if tw, ok := w.(TransparencyWriter); ok {
+		tw.SetFillOpacity(bs.Opacity())
+	}
+	if bs.pattern == "" || bs.pattern == defaultBrushPattern {
+		return
+	}
+	if pw, ok := w.(PatternWriter); ok {
+		pw.SetFillHatch(bs.pattern, bs.hatchSpacing(), bs.hatchWidth())
+	}
+}
+
+// ApplyRect applies the brush to fill the rectangle at x, y, width by height, fitting a gradient to it.
+// Writers unable to fill with gradients fill with the first color of the gradient instead.
+func (bs *BrushStyle) ApplyRect(w Writer, x, y, width, height float64) {
+	bs.Apply(w)
+	if len(bs.stops) == 0 || (bs.kind != "linear" && bs.kind != "radial") {
+		return
+	}
+	gw, ok := w.(GradientWriter)
+	if !ok {
+		w.SetFillColor(bs.stops[0].Color)
+		return
+	}
+	cx, cy := x+width/2, y+height/2
+	if bs.kind == "radial" {
+		gw.SetFillRadialGradient(cx, cy, 0, cx, cy, math.Hypot(width, height)/2, bs.stops)
+		return
+	}
+	// Run the gradient through the center, far enough either way to reach the corners.
+	sin, cos := math.Sincos(bs.angle * math.Pi / 180)
+	l := (width*math.Abs(cos) + height*math.Abs(sin)) / 2
+	gw.SetFillLinearGradient(cx-l*cos, cy+l*sin, cx+l*cos, cy-l*sin, bs.stops)
 }
 
 func (bs *BrushStyle) Clone() *BrushStyle {
@@ -28,18 +80,79 @@ func (bs *BrushStyle) ID() string {
 	return bs.id
 }
 
+func (bs *BrushStyle) hatchSpacing() float64 {
+	if bs.spacing <= 0 {
+		return defaultHatchSpacing
+	}
+	return bs.spacing
+}
+
+func (bs *BrushStyle) hatchWidth() float64 {
+	if bs.width <= 0 {
+		return defaultHatchWidth
+	}
+	return bs.width
+}
+
+// Opacity returns the opacity of the brush, from 0 for transparent to 1 for opaque, the default.
+func (bs *BrushStyle) Opacity() float64 {
+	if bs.opacity == nil {
+		return 1
+	}
+	return *bs.opacity
+}
+
 func (bs *BrushStyle) SetAttrs(prefix string, attrs map[string]string) {
 	if id, ok := attrs[prefix+"id"]; ok {
 		bs.id = id
 	}
 	if color, ok := attrs[prefix+"color"]; ok {
-		bs.color = NamedColor(color)
+		bs.color = NamedPaint(color)
+	}
+	if opacity, ok := parseOpacity(attrs[prefix+"opacity"]); ok {
+		bs.opacity = &opacity
+	}
+	if kind, ok := attrs[prefix+"type"]; ok {
+		bs.kind = kind
+	}
+	if stops, ok := attrs[prefix+"stops"]; ok {
+		bs.stops = parseStops(stops)
+	}
+	if angle, ok := attrs[prefix+"angle"]; ok {
+		bs.angle, _ = strconv.ParseFloat(angle, 64)
+	}
+	if pattern, ok := attrs[prefix+"pattern"]; ok {
+		bs.pattern = pattern
+	}
+	if spacing, ok := attrs[prefix+"spacing"]; ok {
+		bs.spacing = ParseMeasurement(spacing, "pt")
+	}
+	if width, ok := attrs[prefix+"width"]; ok {
+		bs.width = ParseMeasurement(width, "pt")
 	}
 	fmt.Println("color:", bs.color)
 }
 
 func (bs *BrushStyle) String() string {
-	return fmt.Sprintf("BrushStyle id=%s color=%v", bs.id, bs.color)
+	return fmt.Sprintf("BrushStyle id=%s color=%v pattern=%s", bs.id, bs.color, bs.pattern)
+}
+
+// parseStops parses gradient stops separated by commas, each a color optionally followed by an offset
+// given as a fraction or percentage. Stops without offsets are spaced evenly.
+func parseStops(s string) []colors.Stop {
+	fields := strings.Split(s, ",")
+	stops := make([]colors.Stop, 0, len(fields))
+	for i, field := range fields {
+		color, offset := split2(strings.TrimSpace(field), " ")
+		stop := colors.Stop{Color: NamedColor(color)}
+		if o, ok := parseOpacity(strings.TrimSpace(offset)); ok {
+			stop.Offset = o
+		} else if len(fields) > 1 {
+			stop.Offset = float64(i) / float64(len(fields)-1)
+		}
+		stops = append(stops, stop)
+	}
+	return stops
 }
 
 func BrushStyleFor(id string, scope HasScope) *BrushStyle {
@@ -51,7 +164,7 @@ func BrushStyleFor(id string, scope HasScope) *BrushStyle {
 		bs, _ := style.(*BrushStyle)
 		return bs
 	}
-	bs := &BrushStyle{id: "brush_" + id, color: NamedColor(id)}
+	bs := &BrushStyle{id: "brush_" + id, color: NamedPaint(id)}
 	scope.AddStyle(bs)
 	return bs
 }
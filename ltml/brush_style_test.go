@@ -0,0 +1,68 @@
+// Copyright 2016 Brent Rowland.
+// Use of this source code is governed the Apache License, Version 2.0, as described in the LICENSE file.
+
+package ltml
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/rowland/leadtype/ltml/ltpdf"
+	"github.com/rowland/leadtype/pdf"
+)
+
+func TestBrushStyle_opacityRestored(t *testing.T) {
+	doc, err := Parse([]byte(`<ltml>
+  <brush id="tint" color="green" opacity="50%" />
+  <page units="in" margin="1">
+    <p fill="tint">Hello, World!</p>
+  </page>
+</ltml>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := ltpdf.NewDocWriter()
+	w.SetCompressionLevel(pdf.NoCompression)
+	if err := doc.Print(w); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if _, err := w.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	s := buf.String()
+
+	// Each ExtGState is written once, with its fill opacity, and named in the page resources by its object number.
+	opacity := func(name string) string {
+		ref := regexp.MustCompile(`/` + name + ` (\d+) 0 R`).FindStringSubmatch(s)
+		if ref == nil {
+			return ""
+		}
+		gs := regexp.MustCompile(`(?s)\n` + ref[1] + ` 0 obj\n<<.*?/ca ([\d.]+) `).FindStringSubmatch(s)
+		if gs == nil {
+			return ""
+		}
+		return gs[1]
+	}
+	// lastState returns the name of the last ExtGState set before the end of s.
+	lastState := func(s string) string {
+		states := regexp.MustCompile(`/(GS\d+) gs\n`).FindAllStringSubmatch(s, -1)
+		if len(states) == 0 {
+			return ""
+		}
+		return states[len(states)-1][1]
+	}
+	fill := strings.Index(s, " re\nf\n")
+	text := strings.Index(s, " Tj\n")
+	if fill < 0 || text < fill {
+		t.Fatal("Expected background to be filled before text is shown.")
+	}
+	if o := opacity(lastState(s[:fill])); o != "0.5" {
+		t.Errorf("Expected background fill opacity 0.5, got %q", o)
+	}
+	if o := opacity(lastState(s[:text])); o != "1" {
+		t.Errorf("Expected text fill opacity 1, got %q", o)
+	}
+}
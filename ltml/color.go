@@ -20,3 +20,20 @@ func NamedColor(color string) colors.Color {
 	c, _ := colors.NamedColor(color)
 	return c
 }
+
+// NamedPaint returns the paint described by color: a color for NamedColor, or gray(g), cmyk(c, m, y, k)
+// or spot(name, alternate, tint) as for colors.ParsePaint.
+func NamedPaint(color string) colors.Paint {
+	if p, err := colors.ParsePaint(color); err == nil {
+		return p
+	}
+	return NamedColor(color)
+}
+
+// paintOrBlack returns p, or black for a style whose color is unset.
+func paintOrBlack(p colors.Paint) colors.Paint {
+	if p == nil {
+		return colors.Black
+	}
+	return p
+}
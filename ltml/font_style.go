@@ -16,7 +16,7 @@ type FontStyle struct {
 	name string
 	size float64
 
-	color     colors.Color
+	color     colors.Paint
 	strikeout bool
 	style     string
 	underline bool
@@ -28,7 +28,7 @@ type FontStyle struct {
 func (fs *FontStyle) Apply(w Writer) {
 	// fmt.Printf("Applying %s\n", fs)
 	w.SetFont(fs.name, fs.size, options.Options{
-		"color":  colors.Color(fs.color),
+		"color":  paintOrBlack(fs.color),
 		"weight": fs.weight,
 		"style":  fs.style})
 	if fs.lineHeight == 0 {
@@ -69,7 +69,7 @@ func (fs *FontStyle) SetAttrs(prefix string, attrs map[string]string) {
 		}
 	}
 	if color, ok := attrs[prefix+"color"]; ok {
-		fs.color = NamedColor(color)
+		fs.color = NamedPaint(color)
 	}
 	if strikeout, ok := attrs[prefix+"strikeout"]; ok {
 		fs.strikeout = (strikeout == "true")
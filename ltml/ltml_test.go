@@ -156,6 +156,18 @@ func TestSample011(t *testing.T) {
 	writeSamplePDF("test_011_table_layout", t)
 }
 
+func TestSample012(t *testing.T) {
+	writeSamplePDF("test_012_gradients", t)
+}
+
+func TestSample013(t *testing.T) {
+	writeSamplePDF("test_013_patterns", t)
+}
+
+func TestSample014(t *testing.T) {
+	writeSamplePDF("test_014_color_models", t)
+}
+
 func TestSample030(t *testing.T) {
 	writeSamplePDF("test_030_encodings", t)
 	writeSampleHaru("test_030_encodings", t)
@@ -5,6 +5,7 @@ package ltpdf
 
 import (
 	"github.com/rowland/leadtype/afm_fonts"
+	"github.com/rowland/leadtype/colors"
 	"github.com/rowland/leadtype/pdf"
 	"github.com/rowland/leadtype/ttf_fonts"
 )
@@ -17,6 +18,33 @@ func (dw *DocWriter) NewPage() {
 	dw.DocWriter.NewPage()
 }
 
+func (dw *DocWriter) SetFillLinearGradient(x1, y1, x2, y2 float64, stops []colors.Stop) error {
+	grad, err := pdf.NewLinearGradient(x1, y1, x2, y2, stops)
+	if err != nil {
+		return err
+	}
+	dw.SetFillColor(grad)
+	return nil
+}
+
+func (dw *DocWriter) SetFillRadialGradient(x1, y1, r1, x2, y2, r2 float64, stops []colors.Stop) error {
+	grad, err := pdf.NewRadialGradient(x1, y1, r1, x2, y2, r2, stops)
+	if err != nil {
+		return err
+	}
+	dw.SetFillColor(grad)
+	return nil
+}
+
+func (dw *DocWriter) SetFillHatch(style string, spacing, width float64) error {
+	hatch, err := dw.Hatch(style, spacing, width)
+	if err != nil {
+		return err
+	}
+	dw.SetFillColor(hatch)
+	return nil
+}
+
 func (dw *DocWriter) SetLineWidth(width float64) {
 	dw.DocWriter.SetLineWidth(width, "pt")
 }
@@ -11,16 +11,20 @@ import (
 
 type PenStyle struct {
 	id      string
-	color   colors.Color
+	color   colors.Paint
 	width   float64
 	pattern string
+	opacity *float64
 }
 
 func (ps *PenStyle) Apply(w Writer) {
 	// fmt.Printf("Applying %s\n", ps)
-	w.SetLineColor(colors.Color(ps.color))
+	w.SetLineColor(paintOrBlack(ps.color))
 	w.SetLineWidth(ps.width)
 	w.SetLineDashPattern(ps.pattern)
+	if tw, ok := w.(TransparencyWriter); ok {
+		tw.SetStrokeOpacity(ps.Opacity())
+	}
 }
 
 func (ps *PenStyle) Clone() *PenStyle {
@@ -32,12 +36,20 @@ func (ps *PenStyle) ID() string {
 	return ps.id
 }
 
+// Opacity returns the opacity of the pen, from 0 for transparent to 1 for opaque, the default.
+func (ps *PenStyle) Opacity() float64 {
+	if ps.opacity == nil {
+		return 1
+	}
+	return *ps.opacity
+}
+
 func (ps *PenStyle) SetAttrs(prefix string, attrs map[string]string) {
 	if id, ok := attrs[prefix+"id"]; ok {
 		ps.id = id
 	}
 	if color, ok := attrs[prefix+"color"]; ok {
-		ps.color = NamedColor(color)
+		ps.color = NamedPaint(color)
 	}
 	if width, ok := attrs[prefix+"width"]; ok {
 		ps.width = ParseMeasurement(width, "pt")
@@ -45,6 +57,9 @@ func (ps *PenStyle) SetAttrs(prefix string, attrs map[string]string) {
 	if pattern, ok := attrs[prefix+"pattern"]; ok {
 		ps.pattern = pattern
 	}
+	if opacity, ok := parseOpacity(attrs[prefix+"opacity"]); ok {
+		ps.opacity = &opacity
+	}
 }
 
 func (ps *PenStyle) String() string {
@@ -62,7 +77,7 @@ func PenStyleFor(id string, scope HasScope) *PenStyle {
 		ps, _ := style.(*PenStyle)
 		return ps
 	}
-	ps := &PenStyle{id: "pen_" + id, color: NamedColor(id), pattern: defaultPenPattern}
+	ps := &PenStyle{id: "pen_" + id, color: NamedPaint(id), pattern: defaultPenPattern}
 	scope.AddStyle(ps)
 	return ps
 }
@@ -117,13 +117,21 @@ func (widget *StdWidget) LayoutWidget(w Writer) {
 
 func (widget *StdWidget) PaintBackground(w Writer) error {
 	if widget.fill != nil {
-		widget.fill.Apply(w)
-		w.Rectangle2(
-			widget.Left()+widget.MarginLeft(),
-			widget.Top()+widget.MarginTop(),
-			widget.Width()-widget.MarginLeft()-widget.MarginRight(),
-			widget.Height()-widget.MarginTop()-widget.MarginBottom(),
-			false, true, widget.corners, false, false)
+		x := widget.Left() + widget.MarginLeft()
+		y := widget.Top() + widget.MarginTop()
+		width := widget.Width() - widget.MarginLeft() - widget.MarginRight()
+		height := widget.Height() - widget.MarginTop() - widget.MarginBottom()
+		// The brush's opacity is for the background only, not the content painted over it.
+		tw, transparent := w.(TransparencyWriter)
+		var prevOpacity float64
+		if transparent {
+			prevOpacity = tw.SetFillOpacity(1)
+		}
+		widget.fill.ApplyRect(w, x, y, width, height)
+		w.Rectangle2(x, y, width, height, false, true, widget.corners, false, false)
+		if transparent {
+			tw.SetFillOpacity(prevOpacity)
+		}
 	}
 	return nil
 }
@@ -5,6 +5,7 @@ package ltml
 
 import (
 	"encoding/xml"
+	"strconv"
 	"strings"
 )
 
@@ -25,6 +26,19 @@ func MapHasKeyPrefix(attrs map[string]string, prefix string) bool {
 	return false
 }
 
+// parseOpacity parses an opacity, or other fraction from 0 to 1, given as a number or as a percentage.
+func parseOpacity(s string) (float64, bool) {
+	pct := strings.HasSuffix(s, "%")
+	opacity, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+	if err != nil {
+		return 0, false
+	}
+	if pct {
+		opacity /= 100
+	}
+	return opacity, true
+}
+
 func split2(s, sep string) (s1, s2 string) {
 	a := strings.SplitN(s, sep, 2)
 	if len(a) > 0 {
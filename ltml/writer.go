@@ -11,7 +11,7 @@ import (
 )
 
 type Writer interface {
-	FontColor() colors.Color
+	FontColor() colors.Paint
 	Fonts() []*font.Font
 	FontSize() float64
 	LineSpacing() float64
@@ -25,8 +25,8 @@ type Writer interface {
 	Rectangle(x, y, width, height float64, border bool, fill bool)
 	Rectangle2(x, y, width, height float64, border bool, fill bool, corners []float64, path, reverse bool)
 	SetFont(name string, size float64, options options.Options) ([]*font.Font, error)
-	SetFillColor(value interface{}) (prev colors.Color)
-	SetLineColor(value colors.Color) (prev colors.Color)
+	SetFillColor(value interface{}) (prev colors.Paint)
+	SetLineColor(value colors.Paint) (prev colors.Paint)
 	SetLineDashPattern(pattern string) (prev string)
 	SetLineSpacing(lineSpacing float64) (prev float64)
 	SetLineWidth(width float64)
@@ -35,3 +35,21 @@ type Writer interface {
 	Strikeout() bool
 	Underline() bool
 }
+
+// GradientWriter is implemented by writers that can fill with gradients in place of a single colour.
+type GradientWriter interface {
+	SetFillLinearGradient(x1, y1, x2, y2 float64, stops []colors.Stop) error
+	SetFillRadialGradient(x1, y1, r1, x2, y2, r2 float64, stops []colors.Stop) error
+}
+
+// PatternWriter is implemented by writers that can fill with hatch patterns, painted in the fill colour.
+// Spacing and width are in points.
+type PatternWriter interface {
+	SetFillHatch(style string, spacing, width float64) error
+}
+
+// TransparencyWriter is implemented by writers that can paint with partial opacity.
+type TransparencyWriter interface {
+	SetFillOpacity(opacity float64) (prev float64)
+	SetStrokeOpacity(opacity float64) (prev float64)
+}
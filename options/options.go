@@ -77,6 +77,24 @@ func (this Options) Merge(other Options) Options {
 	return result
 }
 
+// PaintDefault returns the paint for key: a colors.Paint, a string parsed by colors.ParsePaint, or an RGB color
+// as int or int32.
+func (this Options) PaintDefault(key string, def colors.Paint) colors.Paint {
+	switch value := this[key].(type) {
+	case colors.Paint:
+		return value
+	case string:
+		if p, err := colors.ParsePaint(value); err == nil {
+			return p
+		}
+	case int:
+		return colors.Color(value)
+	case int32:
+		return colors.Color(value)
+	}
+	return def
+}
+
 func (this Options) StringDefault(key, def string) string {
 	if value, ok := this[key]; ok {
 		switch value := value.(type) {
@@ -5,6 +5,8 @@ package options
 
 import (
 	"testing"
+
+	"github.com/rowland/leadtype/colors"
 )
 
 func TestOptions_BoolDefault(t *testing.T) {
@@ -56,6 +58,16 @@ func TestOptions_Merge(t *testing.T) {
 	expectV(t, "d2", c["d"])
 }
 
+func TestOptions_PaintDefault(t *testing.T) {
+	o := Options{"1st": colors.CMYK{C: 1}, "2nd": "red", "3rd": 0xFF, "4th": "gray(0.5)", "5th": "nonesuch"}
+	expectV(t, colors.GrayLevel(0), o.PaintDefault("missing", colors.GrayLevel(0)))
+	expectV(t, colors.CMYK{C: 1}, o.PaintDefault("1st", colors.Black))
+	expectV(t, colors.Red, o.PaintDefault("2nd", colors.Black))
+	expectV(t, colors.Blue, o.PaintDefault("3rd", colors.Black))
+	expectV(t, colors.GrayLevel(0.5), o.PaintDefault("4th", colors.Black))
+	expectV(t, colors.White, o.PaintDefault("5th", colors.White))
+}
+
 func TestOptions_StringDefault(t *testing.T) {
 	o := Options{"i": 3, "s": "something", "f": 3.14}
 	expectS(t, "3", o.StringDefault("i", ""))
@@ -0,0 +1,86 @@
+// Copyright 2015 Brent Rowland.
+// Use of this source code is governed the Apache License, Version 2.0, as described in the LICENSE file.
+
+package pdf
+
+import (
+	"fmt"
+
+	"github.com/rowland/leadtype/colors"
+)
+
+// Bookmark is an entry in the document outline, as returned by AddBookmark.
+type Bookmark struct {
+	item   *outlineItem
+	open   bool
+	color  colors.Color
+	bold   bool
+	italic bool
+}
+
+// AddBookmark adds a bookmark to the document outline, beneath parent or at the top level if parent is nil.
+// Page numbers start at 1. The bookmark scrolls to y on the page, measured from the top in the page's units,
+// or displays the whole page if y is negative.
+func (dw *DocWriter) AddBookmark(title string, page int, y float64, parent *Bookmark) (*Bookmark, error) {
+	if page < 1 || page > len(dw.pages) {
+		return nil, fmt.Errorf("Bookmark page %d out of range 1-%d.", page, len(dw.pages))
+	}
+	dest := dw.pageDestination(page-1, y)
+	bm := &Bookmark{}
+	if parent == nil {
+		bm.item = newOutlineItem(dw.nextSeq(), 0, textString(title), dw.catalog.outlines, dest)
+		dw.catalog.outlines.add(bm.item)
+	} else {
+		bm.item = newOutlineItem(dw.nextSeq(), 0, textString(title), parent.item, dest)
+		parent.item.add(bm.item)
+	}
+	dw.file.body.add(bm.item)
+	dw.catalog.setPageMode("UseOutlines")
+	return bm, nil
+}
+
+func (bm *Bookmark) Bold() bool {
+	return bm.bold
+}
+
+func (bm *Bookmark) Color() colors.Color {
+	return bm.color
+}
+
+func (bm *Bookmark) Italic() bool {
+	return bm.italic
+}
+
+func (bm *Bookmark) Open() bool {
+	return bm.open
+}
+
+func (bm *Bookmark) SetBold(bold bool) (prev bool) {
+	prev = bm.bold
+	bm.bold = bold
+	bm.item.setStyle(bm.bold, bm.italic)
+	return
+}
+
+// SetColor sets the color of the bookmark's title, where supported by the viewer.
+func (bm *Bookmark) SetColor(color colors.Color) (prev colors.Color) {
+	prev = bm.color
+	bm.color = color
+	bm.item.setColor(color.RGB64())
+	return
+}
+
+func (bm *Bookmark) SetItalic(italic bool) (prev bool) {
+	prev = bm.italic
+	bm.italic = italic
+	bm.item.setStyle(bm.bold, bm.italic)
+	return
+}
+
+// SetOpen determines whether the bookmark's children are initially shown.
+func (bm *Bookmark) SetOpen(open bool) (prev bool) {
+	prev = bm.open
+	bm.open = open
+	bm.item.open = open
+	return
+}
@@ -0,0 +1,47 @@
+// Copyright 2015 Brent Rowland.
+// Use of this source code is governed the Apache License, Version 2.0, as described in the LICENSE file.
+
+package pdf
+
+import (
+	"testing"
+
+	"github.com/rowland/leadtype/colors"
+	"github.com/rowland/leadtype/options"
+)
+
+func TestDocWriter_AddBookmark(t *testing.T) {
+	dw := NewDocWriter()
+	_, err := dw.AddBookmark("Nowhere", 1, 0, nil)
+	check(t, err != nil, "Bookmark to missing page should fail.")
+
+	dw.NewPageWithOptions(options.Options{"units": "in"})
+	dw.NewPage()
+	chapter, err := dw.AddBookmark("Chapter 1", 1, -1, nil)
+	checkFatal(t, err == nil, "AddBookmark should succeed")
+	section, err := dw.AddBookmark("Section 1.1", 2, 1, chapter)
+	checkFatal(t, err == nil, "AddBookmark should succeed")
+
+	expectS(t, "UseOutlines", dw.catalog.pageMode)
+	check(t, dw.catalog.outlines.items[0] == chapter.item, "Chapter should be top level.")
+	check(t, chapter.item.children[0] == section.item, "Section should be child of chapter.")
+	pageRef := stringFromWriter(&indirectObjectRef{dw.pages[1].page})
+	expectS(t, "["+pageRef+"/XYZ null 720 null ] ", stringFromWriter(section.item.dict["Dest"]))
+	pageRef = stringFromWriter(&indirectObjectRef{dw.pages[0].page})
+	expectS(t, "["+pageRef+"/Fit ] ", stringFromWriter(chapter.item.dict["Dest"]))
+}
+
+func TestBookmark_setters(t *testing.T) {
+	dw := NewDocWriter()
+	dw.NewPage()
+	bm, _ := dw.AddBookmark("Bookmark", 1, 0, nil)
+	check(t, !bm.SetOpen(true), "Bookmark should default to closed.")
+	check(t, bm.Open() && bm.item.open, "Bookmark should be open.")
+	check(t, !bm.SetBold(true), "Bookmark should default to regular.")
+	check(t, !bm.SetItalic(true), "Bookmark should default to upright.")
+	check(t, bm.Bold() && bm.Italic(), "Bookmark should be bold and italic.")
+	expectS(t, "3 ", stringFromWriter(bm.item.dict["F"]))
+	bm.SetColor(colors.Red)
+	expectS(t, "[1 0 0 ] ", stringFromWriter(bm.item.dict["C"]))
+	check(t, bm.Color() == colors.Red, "Bookmark should be red.")
+}
@@ -0,0 +1,96 @@
+// Copyright 2015 Brent Rowland.
+// Use of this source code is governed the Apache License, Version 2.0, as described in the LICENSE file.
+
+package pdf
+
+// FillRule determines which points are inside a path that crosses itself or contains other paths.
+type FillRule int
+
+const (
+	// NonZero counts a point as inside if paths wind around it more times in one direction than the other.
+	NonZero = FillRule(iota)
+	// EvenOdd counts a point as inside if a ray from it crosses paths an odd number of times.
+	EvenOdd = FillRule(iota)
+)
+
+// clip intersects the clipping path with the path in progress, which it ends without painting.
+// The pen is returned to where it was before the path was begun.
+func (pw *PageWriter) clip(rule FillRule, loc Location) {
+	if pw.inPath {
+		if rule == EvenOdd {
+			pw.gw.eoClip()
+		} else {
+			pw.gw.clip()
+		}
+		pw.gw.newPath()
+		pw.inPath = false
+	}
+	pw.loc = loc
+}
+
+// startClip ends any text or path in progress before a clipping path is begun, returning the pen's location.
+func (pw *PageWriter) startClip() Location {
+	pw.startGraph()
+	if pw.inPath && pw.autoPath {
+		pw.gw.stroke()
+		pw.inPath = false
+	}
+	return pw.loc
+}
+
+// ClipEllipse restricts painting to the ellipse centred at x, y with radii rx and ry.
+// Clipping lasts until the state saved before it is restored with RestoreState.
+func (pw *PageWriter) ClipEllipse(x, y, rx, ry float64, rule FillRule) {
+	loc := pw.startClip()
+	pw.ellipsePath(x, y, rx, ry, false)
+	pw.clip(rule, loc)
+}
+
+// ClipPath restricts painting to the inside of the path drawn since the last move, using rule,
+// ending the path without painting it. Clipping lasts until the state saved before it is restored with RestoreState.
+func (pw *PageWriter) ClipPath(rule FillRule) {
+	pw.startGraph()
+	pw.clip(rule, pw.loc)
+}
+
+// ClipRectangle restricts painting to a rectangle, with rounded corners if any corner radii are given,
+// as for Rectangle2. Clipping lasts until the state saved before it is restored with RestoreState.
+func (pw *PageWriter) ClipRectangle(x, y, width, height float64, corners []float64, rule FillRule) {
+	loc := pw.startClip()
+	if len(corners) > 0 {
+		pw.roundedRectangle(x, y, width, height, corners, false)
+	} else {
+		pw.gw.rectangle(pw.units.toPts(x), pw.translate(pw.units.toPts(y+height)), pw.units.toPts(width), pw.units.toPts(height))
+		pw.inPath = true
+	}
+	pw.clip(rule, loc)
+}
+
+// ClipText restricts painting to the outlines of text, printed at the current location as if by Print.
+// Clipping lasts until the state saved before it is restored with RestoreState.
+func (pw *PageWriter) ClipText(text string) error {
+	pw.flushText()
+	pw.startText()
+	pw.tw.setRenderingMode(7)
+	err := pw.Print(text)
+	// The clip takes effect at the end of the text object, after which text is filled again.
+	pw.endText()
+	pw.tw.setRenderingMode(0)
+	return err
+}
+
+func (dw *DocWriter) ClipEllipse(x, y, rx, ry float64, rule FillRule) {
+	dw.CurPage().ClipEllipse(x, y, rx, ry, rule)
+}
+
+func (dw *DocWriter) ClipPath(rule FillRule) {
+	dw.CurPage().ClipPath(rule)
+}
+
+func (dw *DocWriter) ClipRectangle(x, y, width, height float64, corners []float64, rule FillRule) {
+	dw.CurPage().ClipRectangle(x, y, width, height, corners, rule)
+}
+
+func (dw *DocWriter) ClipText(text string) error {
+	return dw.CurPage().ClipText(text)
+}
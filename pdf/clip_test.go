@@ -0,0 +1,69 @@
+// Copyright 2015 Brent Rowland.
+// Use of this source code is governed the Apache License, Version 2.0, as described in the LICENSE file.
+
+package pdf
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rowland/leadtype/afm_fonts"
+	"github.com/rowland/leadtype/options"
+)
+
+func TestPageWriter_ClipEllipse(t *testing.T) {
+	dw := NewDocWriter()
+	pw := newPageWriter(dw, options.Options{})
+	pw.MoveTo(10, 10)
+	pw.ClipEllipse(100, 100, 50, 25, EvenOdd)
+	s := pw.stream.String()
+	check(t, strings.HasPrefix(s, "150 692 m\n"), "Ellipse should start at right of centre.")
+	expectI(t, 4, strings.Count(s, " c\n"))
+	check(t, strings.HasSuffix(s, "W*\nn\n"), "Ellipse should clip with even-odd rule.")
+	check(t, !pw.inPath, "Clip should end path.")
+	expectF(t, 10, pw.X())
+	expectF(t, 10, pw.Y())
+}
+
+func TestPageWriter_ClipPath(t *testing.T) {
+	dw := NewDocWriter()
+	pw := newPageWriter(dw, options.Options{})
+	pw.MoveTo(0, 0)
+	pw.LineTo(100, 0)
+	pw.LineTo(100, 100)
+	pw.ClipPath(NonZero)
+	pw.endGraph()
+	expectS(t, "0 792 m\n100 792 l\n100 692 l\nW\nn\n", pw.stream.String())
+}
+
+func TestPageWriter_ClipRectangle(t *testing.T) {
+	dw := NewDocWriter()
+	pw := newPageWriter(dw, options.Options{})
+	pw.SaveState()
+	pw.ClipRectangle(72, 72, 144, 72, nil, NonZero)
+	check(t, pw.RestoreState() == nil, "RestoreState should succeed.")
+	expectS(t, "q\n72 648 144 72 re\nW\nn\nQ\n", pw.stream.String())
+
+	pw.stream.Reset()
+	pw.ClipRectangle(72, 72, 144, 72, []float64{10}, NonZero)
+	s := pw.stream.String()
+	expectI(t, 4, strings.Count(s, " c\n"))
+	check(t, strings.HasSuffix(s, "W\nn\n"), "Rounded rectangle should clip.")
+}
+
+func TestPageWriter_ClipText(t *testing.T) {
+	fc, err := afm_fonts.New("../afm/data/fonts/*.afm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dw := NewDocWriter()
+	dw.AddFontSource(fc)
+	pw := newPageWriter(dw, options.Options{})
+	pw.SetFont("Helvetica", 36, options.Options{})
+	pw.MoveTo(72, 72)
+	err = pw.ClipText("Clipped")
+	check(t, err == nil, "ClipText should succeed.")
+	s := pw.stream.String()
+	check(t, strings.HasPrefix(s, "BT\n7 Tr\n"), "Text should be rendered as clip.")
+	check(t, strings.HasSuffix(s, "(Clipped) Tj\nET\n0 Tr\n"), "Rendering mode should be reset after text.")
+}
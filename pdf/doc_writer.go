@@ -4,8 +4,12 @@
 package pdf
 
 import (
+	"compress/zlib"
+	"errors"
 	"fmt"
 	"io"
+	"sort"
+	"time"
 
 	"github.com/rowland/leadtype/codepage"
 	"github.com/rowland/leadtype/colors"
@@ -14,8 +18,22 @@ import (
 	"github.com/rowland/leadtype/rich_text"
 )
 
+// Compression levels for SetCompressionLevel. Levels 1 through 9 may also be used.
+const (
+	NoCompression      = zlib.NoCompression
+	BestSpeed          = zlib.BestSpeed
+	BestCompression    = zlib.BestCompression
+	DefaultCompression = zlib.DefaultCompression
+)
+
+var (
+	errNoFont             = errors.New("No font specified.")
+	errFontMissingMetrics = errors.New("Font missing metrics.")
+)
+
 type DocWriter struct {
 	pages         []*PageWriter
+	flushed       []pageTarget // the first pages of a streaming DocWriter, written and released
 	nextSeq       func() int
 	file          *file
 	catalog       *catalog
@@ -27,6 +45,29 @@ type DocWriter struct {
 	fontSources   font.FontSources
 	fontKeys      map[string]string
 	fontEncodings map[string]*fontEncoding
+	fontFiles     map[string]*fontFile
+	toUnicodes    map[string]*stream
+	images        map[string]*docImage
+	extGStates    map[extGStateKey]string
+	patterns      map[string]string
+	separations   map[colors.Spot]string
+	cells         []*Pattern
+	hatches       map[string]*Pattern
+	forms         []*Form
+	templates     []*PageWriter
+	imports       map[string]*importedFile
+	links         []*link
+	dests         map[string]array
+	composite     map[string]bool
+	fallback      bool
+	compression   int
+	objectStreams bool
+	pdfa          bool
+	info          Info
+	security      *securityHandler
+	id            []byte
+	output        io.Writer
+	err           error
 }
 
 func NewDocWriter() *DocWriter {
@@ -38,11 +79,21 @@ func NewDocWriter() *DocWriter {
 	file.body.add(pages, outlines, catalog)
 	file.trailer.setRoot(catalog)
 	resources := newResources(nextSeq(), 0)
-	resources.setProcSet(nameArray("PDF", "Text", "ImageB", "ImageC"))
+	resources.setProcSet(nameArray("PDF", "Text", "ImageB", "ImageC", "ImageI"))
 	file.body.add(resources)
 	fontSources := make(font.FontSources, 0, 2)
 	fontKeys := make(map[string]string)
 	fontEncodings := make(map[string]*fontEncoding)
+	fontFiles := make(map[string]*fontFile)
+	toUnicodes := make(map[string]*stream)
+	images := make(map[string]*docImage)
+	extGStates := make(map[extGStateKey]string)
+	patterns := make(map[string]string)
+	separations := make(map[colors.Spot]string)
+	hatches := make(map[string]*Pattern)
+	imports := make(map[string]*importedFile)
+	dests := make(map[string]array)
+	composite := make(map[string]bool)
 	return &DocWriter{
 		nextSeq:       nextSeq,
 		file:          file,
@@ -51,7 +102,18 @@ func NewDocWriter() *DocWriter {
 		options:       options.Options{},
 		fontSources:   fontSources,
 		fontKeys:      fontKeys,
-		fontEncodings: fontEncodings}
+		fontEncodings: fontEncodings,
+		fontFiles:     fontFiles,
+		toUnicodes:    toUnicodes,
+		images:        images,
+		extGStates:    extGStates,
+		patterns:      patterns,
+		separations:   separations,
+		hatches:       hatches,
+		imports:       imports,
+		dests:         dests,
+		composite:     composite,
+		compression:   DefaultCompression}
 }
 
 func nextSeqFunc() func() int {
@@ -70,14 +132,39 @@ func (dw *DocWriter) AddFontSource(fontSource font.FontSource) {
 	dw.fontSources = append(dw.fontSources, fontSource)
 }
 
+func (dw *DocWriter) addFontRunes(f *font.Font, text string) {
+	if ff, ok := dw.fontFiles[f.Filename()]; ok {
+		ff.addRunes(text)
+	}
+}
+
+// CompressionLevel returns the level used to compress streams.
+func (dw *DocWriter) CompressionLevel() int {
+	return dw.compression
+}
+
+// compress compresses s at the document's compression level.
+// Should compression fail, s is left uncompressed, which is still valid.
+func (dw *DocWriter) compress(s *stream) {
+	s.compress(dw.compression)
+}
+
+// CompositeFallback reports whether text that cannot be encoded in any codepage is shown with a composite font.
+func (dw *DocWriter) CompositeFallback() bool {
+	return dw.fallback
+}
+
 func (dw *DocWriter) CurPage() *PageWriter {
+	if n := len(dw.templates); n > 0 {
+		return dw.templates[n-1]
+	}
 	if dw.curPage == nil {
 		return dw.NewPage()
 	}
 	return dw.curPage
 }
 
-func (dw *DocWriter) FontColor() colors.Color {
+func (dw *DocWriter) FontColor() colors.Paint {
 	return dw.CurPage().FontColor()
 }
 
@@ -90,33 +177,35 @@ func useStandardEncoding(family string) bool {
 	}
 }
 
-func (dw *DocWriter) fontKey(f *font.Font, cpi codepage.CodepageIndex) string {
+// checkFont returns an error if f cannot be used to show text.
+func checkFont(f *font.Font) error {
 	if f == nil {
-		panic("fontKey: No font specified.")
+		return errNoFont
 	}
 	if !f.HasMetrics() {
-		panic("fontKey: font missing metrics.")
+		return errFontMissingMetrics
+	}
+	return nil
+}
+
+// fontKey returns the resource key of a simple font for f, encoded with codepage cpi.
+func (dw *DocWriter) fontKey(f *font.Font, cpi codepage.CodepageIndex) (string, error) {
+	if err := checkFont(f); err != nil {
+		return "", err
+	}
+	if err := dw.checkEmbeddable(f); err != nil {
+		return "", err
 	}
 	name := fmt.Sprintf("%s/%s-%s", f.PostScriptName(), cpi, f.SubType())
 	if key, ok := dw.fontKeys[name]; ok {
-		return key
+		return key, nil
 	}
-	descriptor := newFontDescriptor(
-		dw.nextSeq(), 0,
-		f.PostScriptName(), f.Family(),
-		f.Flags(),
-		f.BoundingBox(),
-		0, // missingWidth
-		f.StemV(),
-		0, // stemH
-		f.ItalicAngle(),
-		f.CapHeight(),
-		f.XHeight(),
-		f.Ascent(),
-		f.Descent(),
-		f.Leading(),
-		0, 0) // maxWidth, avgWidth
-	dw.file.body.add(descriptor)
+	baseFont := f.PostScriptName()
+	ff := dw.fontFile(f)
+	if ff != nil {
+		baseFont = ff.baseFont()
+	}
+	descriptor := dw.fontDescriptor(f, baseFont, ff)
 	key := fmt.Sprintf("F%d", len(dw.fontKeys))
 	dw.fontKeys[name] = key
 	widths := dw.widthsForFontCodepage(f, cpi)
@@ -138,11 +227,13 @@ func (dw *DocWriter) fontKey(f *font.Font, cpi codepage.CodepageIndex) string {
 				dw.file.body.add(encoding)
 				dw.fontEncodings[cpi.String()] = encoding
 			}
+			toUnicode := dw.toUnicode(cpi)
 			font = newType1Font(
 				dw.nextSeq(), 0,
 				f.PostScriptName(),
 				32, 255, widths,
 				descriptor, &indirectObjectRef{encoding})
+			font.setToUnicode(toUnicode)
 		}
 	case "TrueType":
 		encoding, ok := dw.fontEncodings[cpi.String()]
@@ -152,15 +243,117 @@ func (dw *DocWriter) fontKey(f *font.Font, cpi codepage.CodepageIndex) string {
 			dw.file.body.add(encoding)
 			dw.fontEncodings[cpi.String()] = encoding
 		}
+		toUnicode := dw.toUnicode(cpi)
 		font = newTrueTypeFont(
 			dw.nextSeq(), 0,
-			f.PostScriptName(),
+			baseFont,
 			32, 255, widths,
 			descriptor, &indirectObjectRef{encoding})
+		font.setToUnicode(toUnicode)
+	}
+	dw.file.body.add(font)
+	dw.resources.fonts[key] = &indirectObjectRef{font}
+	return key, nil
+}
+
+// compositeFontKey returns the resource key of a Type0 font with Identity-H encoding for f,
+// whose text is shown as 2-byte glyph indexes.
+func (dw *DocWriter) compositeFontKey(f *font.Font) (string, error) {
+	if err := checkFont(f); err != nil {
+		return "", err
 	}
+	if err := dw.checkEmbeddable(f); err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("%s/Identity-H-%s", f.PostScriptName(), f.SubType())
+	if key, ok := dw.fontKeys[name]; ok {
+		return key, nil
+	}
+	ff := dw.fontFile(f)
+	if ff == nil {
+		return "", fmt.Errorf("Font %s is not TrueType and cannot be used as a composite font.", f.Family())
+	}
+	// Glyph indexes mean nothing to the font a viewer substitutes for one not embedded.
+	if ff.stream == nil {
+		return "", fmt.Errorf("Font %s is not licensed for embedding and cannot be used as a composite font.", f.Family())
+	}
+	baseFont := ff.baseFont()
+	descriptor := dw.fontDescriptor(f, baseFont, ff)
+	key := fmt.Sprintf("F%d", len(dw.fontKeys))
+	dw.fontKeys[name] = key
+	ff.cidFont = newCIDFontType2(dw.nextSeq(), 0, baseFont, descriptor)
+	dw.file.body.add(ff.cidFont)
+	ff.toUnicode = newStream(dw.nextSeq(), 0, nil)
+	dw.file.body.add(ff.toUnicode)
+	font := newType0Font(dw.nextSeq(), 0, baseFont, "Identity-H", ff.cidFont)
+	font.setToUnicode(ff.toUnicode)
 	dw.file.body.add(font)
 	dw.resources.fonts[key] = &indirectObjectRef{font}
-	return key
+	return key, nil
+}
+
+func (dw *DocWriter) fontDescriptor(f *font.Font, baseFont string, ff *fontFile) *fontDescriptor {
+	descriptor := newFontDescriptor(
+		dw.nextSeq(), 0,
+		baseFont, f.Family(),
+		f.Flags(),
+		f.BoundingBox(),
+		0, // missingWidth
+		f.StemV(),
+		0, // stemH
+		f.ItalicAngle(),
+		f.CapHeight(),
+		f.XHeight(),
+		f.Ascent(),
+		f.Descent(),
+		f.Leading(),
+		0, 0) // maxWidth, avgWidth
+	if ff != nil && ff.stream != nil {
+		descriptor.setFontFile2(ff.stream)
+	}
+	dw.file.body.add(descriptor)
+	return descriptor
+}
+
+// fontFile returns the usage record for TrueType font f, creating it on first use.
+// A stream for the font program is only created for fonts licensed for embedding.
+// Nil is returned for fonts that are not TrueType.
+func (dw *DocWriter) fontFile(f *font.Font) *fontFile {
+	if ff, ok := dw.fontFiles[f.Filename()]; ok {
+		return ff
+	}
+	if f.SubType() != "TrueType" {
+		return nil
+	}
+	var stream *stream
+	if f.Embeddable() {
+		stream = newStream(dw.nextSeq(), 0, nil)
+		dw.file.body.add(stream)
+	}
+	ff := newFontFile(f, stream)
+	dw.fontFiles[f.Filename()] = ff
+	return ff
+}
+
+func (dw *DocWriter) embedFontFiles() error {
+	filenames := make([]string, 0, len(dw.fontFiles))
+	for filename := range dw.fontFiles {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+	for _, filename := range filenames {
+		ff := dw.fontFiles[filename]
+		if err := ff.embed(); err != nil {
+			return err
+		}
+		if ff.stream != nil {
+			dw.compress(ff.stream)
+		}
+		if ff.toUnicode != nil {
+			dw.compress(ff.toUnicode)
+		}
+	}
+	return nil
 }
 
 func (dw *DocWriter) Fonts() []*font.Font {
@@ -188,23 +381,35 @@ func (dw *DocWriter) indexOfPage(pw *PageWriter) int {
 	return -1
 }
 
+// Info returns the document information set with SetInfo.
+func (dw *DocWriter) Info() Info {
+	return dw.info
+}
+
+// ObjectStreams reports whether the document is written with object streams and a cross-reference stream.
+func (dw *DocWriter) ObjectStreams() bool {
+	return dw.objectStreams
+}
+
 func (dw *DocWriter) inPage() bool {
 	return dw.curPage != nil
 }
 
 func (dw *DocWriter) insertPage(pw *PageWriter, index int) {
-	pages := make([]*PageWriter, 0, len(dw.pages)+1)
-	pages = append(pages, dw.pages[:index+1]...)
-	pages = append(pages, pw)
-	pages = append(pages, dw.pages[index+1:]...)
-	dw.pages = pages
+	dw.pages = append(dw.pages, nil)
+	copy(dw.pages[index+2:], dw.pages[index+1:])
+	dw.pages[index+1] = pw
+}
+
+func (dw *DocWriter) isComposite(f *font.Font) bool {
+	return dw.composite[f.Filename()] && f.SubType() == "TrueType"
 }
 
 func (dw *DocWriter) LineCapStyle() LineCapStyle {
 	return dw.CurPage().LineCapStyle()
 }
 
-func (dw *DocWriter) LineColor() colors.Color {
+func (dw *DocWriter) LineColor() colors.Paint {
 	return dw.CurPage().LineColor()
 }
 
@@ -246,6 +451,8 @@ func (dw *DocWriter) NewPageAfter(pw *PageWriter) *PageWriter {
 	} else {
 		i = dw.indexOfPage(pw)
 	}
+	// Finishing the current page of a streaming DocWriter releases it, so it is found first.
+	dw.finishPage()
 	if i >= 0 {
 		dw.curPage = clonePageWriter(pw)
 		dw.insertPage(dw.curPage, i)
@@ -255,6 +462,7 @@ func (dw *DocWriter) NewPageAfter(pw *PageWriter) *PageWriter {
 }
 
 func (dw *DocWriter) NewPageWithOptions(options options.Options) *PageWriter {
+	dw.finishPage()
 	dw.curPage = newPageWriter(dw, dw.options.Merge(options))
 	dw.pages = append(dw.pages, dw.curPage)
 	return dw.curPage
@@ -290,6 +498,10 @@ func (dw *DocWriter) Print(text string) (err error) {
 	return dw.CurPage().Print(text)
 }
 
+func (dw *DocWriter) PrintImage(r io.Reader, x, y, width, height float64) error {
+	return dw.CurPage().PrintImage(r, x, y, width, height)
+}
+
 func (dw *DocWriter) PrintParagraph(para []*rich_text.RichText, options options.Options) {
 	dw.CurPage().PrintParagraph(para, options)
 }
@@ -314,7 +526,35 @@ func (dw *DocWriter) ResetFonts() {
 	dw.CurPage().ResetFonts()
 }
 
-func (dw *DocWriter) SetFillColor(color interface{}) (prev colors.Color) {
+// SetCompressionLevel sets the zlib level at which page content, fonts and other streams are compressed with FlateDecode.
+// NoCompression writes streams without a filter, which is useful for debugging.
+// Levels outside the range DefaultCompression to BestCompression are treated as DefaultCompression.
+func (dw *DocWriter) SetCompressionLevel(level int) (prev int) {
+	prev = dw.compression
+	if level < DefaultCompression || level > BestCompression {
+		level = DefaultCompression
+	}
+	dw.compression = level
+	return
+}
+
+// SetCompositeFallback determines whether text in a TrueType font that cannot be encoded in any of the supported codepages
+// is shown with a composite (Type0) font instead of being omitted. Text in fonts not licensed for embedding is still omitted.
+func (dw *DocWriter) SetCompositeFallback(fallback bool) (prev bool) {
+	prev = dw.fallback
+	dw.fallback = fallback
+	return
+}
+
+// setErr records the first error that could not be returned where it occurred, such as while showing text,
+// to be returned by WriteTo or Close.
+func (dw *DocWriter) setErr(err error) {
+	if dw.err == nil {
+		dw.err = err
+	}
+}
+
+func (dw *DocWriter) SetFillColor(color interface{}) (prev colors.Paint) {
 	return dw.CurPage().SetFillColor(color)
 }
 
@@ -322,7 +562,7 @@ func (dw *DocWriter) SetFont(name string, size float64, options options.Options)
 	return dw.CurPage().SetFont(name, size, options)
 }
 
-func (dw *DocWriter) SetFontColor(color interface{}) (lastColor colors.Color) {
+func (dw *DocWriter) SetFontColor(color interface{}) (lastColor colors.Paint) {
 	return dw.CurPage().SetFontColor(color)
 }
 
@@ -334,7 +574,15 @@ func (dw *DocWriter) SetFontStyle(style string) (prev string, err error) {
 	return dw.CurPage().SetFontStyle(style)
 }
 
-func (dw *DocWriter) SetLineColor(color colors.Color) (prev colors.Color) {
+// SetInfo sets the document information written to the Info dictionary and XMP metadata.
+// If Producer is empty, "leadtype" is written.
+func (dw *DocWriter) SetInfo(info Info) (prev Info) {
+	prev = dw.info
+	dw.info = info
+	return
+}
+
+func (dw *DocWriter) SetLineColor(color colors.Paint) (prev colors.Paint) {
 	return dw.CurPage().SetLineColor(color)
 }
 
@@ -354,10 +602,24 @@ func (dw *DocWriter) SetLineWidth(width float64, units string) (prev float64) {
 	return dw.CurPage().SetLineWidth(width, units)
 }
 
+// SetObjectStreams determines whether the document is written in the compact form introduced by PDF 1.5,
+// with objects other than streams packed into compressed object streams and a cross-reference stream
+// in place of the xref table. The header version is raised to 1.5 if necessary.
+// By default, every object is written separately with an xref table, as older readers expect.
+func (dw *DocWriter) SetObjectStreams(objectStreams bool) (prev bool) {
+	prev = dw.objectStreams
+	dw.objectStreams = objectStreams
+	return
+}
+
 func (dw *DocWriter) SetOptions(options options.Options) {
 	dw.options = options
 }
 
+func (dw *DocWriter) SetTextAngle(angle float64) (prev float64) {
+	return dw.CurPage().SetTextAngle(angle)
+}
+
 func (dw *DocWriter) SetUnderline(underline bool) (prev bool) {
 	return dw.CurPage().SetUnderline(underline)
 }
@@ -373,6 +635,22 @@ func (dw *DocWriter) Strikeout() bool {
 	return dw.CurPage().Strikeout()
 }
 
+func (dw *DocWriter) TextAngle() float64 {
+	return dw.CurPage().TextAngle()
+}
+
+// toUnicode returns the ToUnicode CMap shared by simple fonts encoded with codepage cpi, creating it on first use.
+func (dw *DocWriter) toUnicode(cpi codepage.CodepageIndex) *stream {
+	if cmap, ok := dw.toUnicodes[cpi.String()]; ok {
+		return cmap
+	}
+	cmap := newStream(dw.nextSeq(), 0, toUnicodeCMap(1, codepageToUnicode(cpi)))
+	dw.compress(cmap)
+	dw.file.body.add(cmap)
+	dw.toUnicodes[cpi.String()] = cmap
+	return cmap
+}
+
 func (dw *DocWriter) Underline() bool {
 	return dw.CurPage().Underline()
 }
@@ -398,6 +676,9 @@ func (dw *DocWriter) Write(text []byte) (n int, err error) {
 
 // WriteTo implements io.WriterTo.
 func (dw *DocWriter) WriteTo(wr io.Writer) (int64, error) {
+	if dw.output != nil {
+		return 0, errStreaming
+	}
 	if len(dw.pages) == 0 {
 		dw.NewPage()
 	}
@@ -405,8 +686,78 @@ func (dw *DocWriter) WriteTo(wr io.Writer) (int64, error) {
 		pw.close()
 	}
 	dw.curPage = nil
-	dw.file.write(wr)
-	return 0, nil
+	if err := dw.finish(); err != nil {
+		return 0, err
+	}
+	return dw.file.writeTo(wr)
+}
+
+// finish completes the objects shared by all pages, once every page has been closed.
+// It first returns any error recorded while drawing the pages.
+func (dw *DocWriter) finish() error {
+	if dw.err != nil {
+		return dw.err
+	}
+	if err := dw.resolveLinks(); err != nil {
+		return err
+	}
+	if err := dw.embedFontFiles(); err != nil {
+		return err
+	}
+	dw.writeInfo(time.Now())
+	if dw.pdfa {
+		dw.writeOutputIntent()
+	}
+	if dw.file.body.security == nil {
+		if err := dw.encrypt(dw.id); err != nil {
+			return err
+		}
+	}
+	if dw.objectStreams {
+		dw.file.header.requireVersion(1.5)
+	}
+	dw.file.objectStreams = dw.objectStreams
+	dw.file.compression = dw.compression
+	return nil
+}
+
+// infoAt returns the document information to be written, with the default producer
+// and, if none was set, a creation date of now.
+func (dw *DocWriter) infoAt(now time.Time) Info {
+	info := dw.info
+	if info.Producer == "" {
+		info.Producer = defaultProducer
+	}
+	if info.CreationDate.IsZero() {
+		info.CreationDate = now
+	}
+	return info
+}
+
+// writeInfo adds the Info dictionary and XMP metadata to the document and sets the file identifier,
+// unless it was already derived for encryption.
+func (dw *DocWriter) writeInfo(now time.Time) {
+	info := dw.infoAt(now)
+	if dw.pdfa && info.ModDate.IsZero() {
+		info.ModDate = info.CreationDate
+	}
+	infoDict := newDictionaryObject(dw.nextSeq(), 0)
+	infoDict.dict = info.dictionary()
+	dw.file.body.add(infoDict)
+	dw.file.trailer.setInfo(infoDict)
+
+	metadata := newMetadataStream(dw.nextSeq(), 0, info.xmp(dw.pdfa))
+	// PDF/A forbids filters on the metadata stream, so that it can be read without decoding.
+	if !dw.pdfa {
+		dw.compress(metadata)
+	}
+	dw.file.body.add(metadata)
+	dw.catalog.setMetadata(metadata)
+
+	if dw.id == nil {
+		dw.id = info.fileID(len(dw.file.body.list))
+	}
+	dw.file.trailer.setID(dw.id)
 }
 
 func (dw *DocWriter) X() float64 {
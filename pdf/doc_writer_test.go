@@ -5,12 +5,17 @@ package pdf
 
 import (
 	"bytes"
+	"errors"
+	"io/ioutil"
+	"strings"
 	"testing"
 
 	"github.com/rowland/leadtype/afm_fonts"
 	"github.com/rowland/leadtype/codepage"
 	"github.com/rowland/leadtype/colors"
+	"github.com/rowland/leadtype/font"
 	"github.com/rowland/leadtype/options"
+	"github.com/rowland/leadtype/rich_text"
 	"github.com/rowland/leadtype/ttf_fonts"
 )
 
@@ -50,6 +55,24 @@ func TestDocWriter_Close(t *testing.T) {
 	check(t, len(dw.pages) == 1, "DocWriter pages should have minimum of 1 page after Close")
 }
 
+func TestDocWriter_compress(t *testing.T) {
+	var buf bytes.Buffer
+	dw := NewDocWriter()
+	dw.MoveTo(72, 72)
+	dw.LineTo(144, 144)
+	dw.WriteTo(&buf)
+	check(t, bytes.Contains(buf.Bytes(), []byte("/Filter /FlateDecode")), "Page content should be compressed by default.")
+
+	buf.Reset()
+	dw = NewDocWriter()
+	dw.SetCompressionLevel(NoCompression)
+	dw.MoveTo(72, 72)
+	dw.LineTo(144, 144)
+	dw.WriteTo(&buf)
+	check(t, !bytes.Contains(buf.Bytes(), []byte("/Filter")), "Page content should not be compressed.")
+	check(t, bytes.Contains(buf.Bytes(), []byte("144 648 l")), "Page content should be readable.")
+}
+
 func TestDocWriter_fontKey(t *testing.T) {
 	fc, err := ttf_fonts.New("/Library/Fonts/*.ttf")
 	if err != nil {
@@ -63,14 +86,41 @@ func TestDocWriter_fontKey(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	key1 := dw.fontKey(fonts[0], codepage.CodepageIndex(0))
+	key1, _ := dw.fontKey(fonts[0], codepage.CodepageIndex(0))
 	check(t, key1 == "F0", "1st fontKey should be F0.")
-	key2 := dw.fontKey(fonts[0], codepage.CodepageIndex(0))
+	key2, _ := dw.fontKey(fonts[0], codepage.CodepageIndex(0))
 	check(t, key2 == "F0", "Same font and cpi should yield same key.")
-	key3 := dw.fontKey(fonts[0], codepage.CodepageIndex(1))
+	key3, _ := dw.fontKey(fonts[0], codepage.CodepageIndex(1))
 	check(t, key3 == "F1", "2nd fontKey should be F1.")
 }
 
+func TestDocWriter_fontKey_errors(t *testing.T) {
+	dw := NewDocWriter()
+	_, err := dw.fontKey(nil, codepage.Idx_CP1252)
+	check(t, err == errNoFont, "fontKey should fail without font.")
+	_, err = dw.fontKey(&font.Font{}, codepage.Idx_CP1252)
+	check(t, err == errFontMissingMetrics, "fontKey should fail for font without metrics.")
+	_, err = dw.compositeFontKey(&font.Font{})
+	check(t, err == errFontMissingMetrics, "compositeFontKey should fail for font without metrics.")
+}
+
+func TestDocWriter_fontKey_objectOrder(t *testing.T) {
+	fc, err := afm_fonts.New("../afm/data/fonts/*.afm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dw := NewDocWriter()
+	dw.AddFontSource(fc)
+	fonts, err := dw.AddFont("Helvetica", options.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dw.fontKey(fonts[0], codepage.Idx_CP1252)
+	for i, obj := range dw.file.body.list {
+		expectI(t, i+1, obj.Seq())
+	}
+}
+
 func TestDocWriter_indexOfPage(t *testing.T) {
 	dw := NewDocWriter()
 
@@ -173,6 +223,32 @@ func TestDocWriter_PageWidth(t *testing.T) {
 	expectF(t, 8500, dw.PageWidth())
 }
 
+func TestDocWriter_SetCompressionLevel(t *testing.T) {
+	dw := NewDocWriter()
+	expectI(t, DefaultCompression, dw.CompressionLevel())
+	expectI(t, DefaultCompression, dw.SetCompressionLevel(NoCompression))
+	expectI(t, NoCompression, dw.SetCompressionLevel(BestCompression))
+	expectI(t, BestCompression, dw.SetCompressionLevel(42))
+	expectI(t, DefaultCompression, dw.CompressionLevel())
+}
+
+func TestDocWriter_SetObjectStreams(t *testing.T) {
+	dw := NewDocWriter()
+	check(t, !dw.ObjectStreams(), "Object streams should be off by default.")
+	expectB(t, false, dw.SetObjectStreams(true))
+	check(t, dw.ObjectStreams(), "Object streams should be on.")
+
+	var buf bytes.Buffer
+	dw.WriteTo(&buf)
+	pdf := buf.String()
+	check(t, strings.HasPrefix(pdf, "%PDF-1.5\n"), "Version should be raised to 1.5.")
+	check(t, strings.Contains(pdf, "/Type /ObjStm "), "Objects should be packed into an object stream.")
+	check(t, strings.Contains(pdf, "/Type /XRef "), "Document should have a cross-reference stream.")
+	check(t, !strings.Contains(pdf, "/Type /Catalog "), "Catalog should be compressed.")
+	check(t, !strings.Contains(pdf, "\nxref\n"), "Document should not have an xref table.")
+	check(t, !strings.Contains(pdf, "\ntrailer\n"), "Document should not have a trailer dictionary.")
+}
+
 func TestDocWriter_SetFont_TrueType(t *testing.T) {
 	dw := NewDocWriter()
 
@@ -229,6 +305,65 @@ func TestDocWriter_SetOptions(t *testing.T) {
 	check(t, dw.options["units"] == "in", "Default units should be in")
 }
 
+// failingWriter accepts limit bytes, then fails.
+type failingWriter struct {
+	limit int
+}
+
+var errWriteFailed = errors.New("Write failed.")
+
+func (fw *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > fw.limit {
+		n := fw.limit
+		fw.limit = 0
+		return n, errWriteFailed
+	}
+	fw.limit -= len(p)
+	return len(p), nil
+}
+
+func TestDocWriter_WriteTo(t *testing.T) {
+	var buf bytes.Buffer
+	dw := NewDocWriter()
+	n, err := dw.WriteTo(&buf)
+	check(t, err == nil, "WriteTo should succeed.")
+	expectI(t, buf.Len(), int(n))
+
+	dw = NewDocWriter()
+	n, err = dw.WriteTo(&failingWriter{100})
+	check(t, err == errWriteFailed, "WriteTo should return write error.")
+	expectI(t, 100, int(n))
+
+	dw = NewDocWriter()
+	dw.SetObjectStreams(true)
+	n, err = dw.WriteTo(&failingWriter{100})
+	check(t, err == errWriteFailed, "WriteTo should return write error with object streams.")
+	expectI(t, 100, int(n))
+}
+
+func TestDocWriter_WriteTo_fontError(t *testing.T) {
+	dw := NewDocWriter()
+	pw := dw.NewPage()
+	pw.PrintRichText(&rich_text.RichText{Text: "Hello", Font: &font.Font{}, FontSize: 12})
+	pw.flushText()
+	_, err := dw.WriteTo(ioutil.Discard)
+	check(t, err == errFontMissingMetrics, "WriteTo should return error from showing text with a font missing metrics.")
+
+	fc, err := afm_fonts.New("../afm/data/fonts/*.afm")
+	checkFatal(t, err == nil, "Fonts should load.")
+	dw = NewDocWriter()
+	dw.AddFontSource(fc)
+	pw = dw.NewPage()
+	fonts, err := pw.AddFont("Helvetica", options.Options{})
+	checkFatal(t, err == nil, "AddFont should succeed.")
+	rt, err := (&rich_text.RichText{}).Add("Hello", fonts, 12, options.Options{})
+	checkFatal(t, err == nil, "Add should succeed.")
+	pw.PrintRichText(rt)
+	pw.flushText()
+	_, err = dw.WriteTo(ioutil.Discard)
+	check(t, err == nil, "WriteTo should ignore empty pieces without fonts.")
+}
+
 // TODO: TestPagesAcross
 // TODO: TestPagesDown
 // TODO: TestPagesUp
@@ -6,18 +6,25 @@ package pdf
 import "github.com/rowland/leadtype/colors"
 
 type drawState struct {
+	blendMode       string
 	charSpacing     float64
-	fillColor       colors.Color
-	fontColor       colors.Color
+	fillColor       colors.Paint
+	fillGradient    *Gradient
+	fillOpacity     float64
+	fillPattern     *Pattern
+	fillPatternName string // the pattern last set, which may have been made for fillGradient or fillPattern
+	fontColor       colors.Paint
 	fontKey         string
 	fontSize        float64
 	lineCapStyle    LineCapStyle
-	lineColor       colors.Color
+	lineColor       colors.Paint
 	lineDashPattern string
 	lineSpacing     float64
 	lineWidth       float64
 	loc             Location
 	strikeout       bool
+	strokeOpacity   float64
+	textAngle       float64
 	underline       bool
 	wordSpacing     float64
 }
@@ -0,0 +1,156 @@
+// Copyright 2015 Brent Rowland.
+// Use of this source code is governed the Apache License, Version 2.0, as described in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"hash/crc32"
+	"sort"
+
+	"github.com/rowland/leadtype/font"
+)
+
+// fontFile tracks the runes printed in a TrueType font,
+// so that the widths of a composite font can be written and, for fonts licensed for embedding,
+// a subset of the font program can be embedded when the document is written.
+type fontFile struct {
+	font      *font.Font
+	tag       string
+	runes     map[rune]bool
+	stream    *stream
+	cidFont   *cidFont
+	toUnicode *stream
+}
+
+func newFontFile(f *font.Font, stream *stream) *fontFile {
+	ff := &fontFile{
+		font:   f,
+		runes:  make(map[rune]bool),
+		stream: stream,
+	}
+	if stream != nil {
+		ff.tag = subsetTag(f.PostScriptName(), stream.seq)
+	}
+	return ff
+}
+
+func (ff *fontFile) addRunes(text string) {
+	for _, r := range text {
+		ff.runes[r] = true
+	}
+}
+
+// baseFont returns the PostScript name, prefixed with the subset tag if the font is embedded.
+func (ff *fontFile) baseFont() string {
+	if ff.tag == "" {
+		return ff.font.PostScriptName()
+	}
+	return ff.tag + "+" + ff.font.PostScriptName()
+}
+
+func (ff *fontFile) embed() error {
+	if ff.cidFont != nil {
+		ff.cidFont.setWidths(cidWidths(ff.glyphWidths()))
+	}
+	if ff.toUnicode != nil {
+		ff.toUnicode.data = toUnicodeCMap(2, ff.glyphRunes())
+	}
+	if ff.stream == nil {
+		return nil
+	}
+	var buf bytes.Buffer
+	if err := ff.font.Subset(&buf, ff.glyphs()); err != nil {
+		return err
+	}
+	ff.stream.data = buf.Bytes()
+	ff.stream.dict["Length1"] = integer(buf.Len())
+	return nil
+}
+
+// glyphIndexes encodes text as a sequence of 2-byte glyph indexes, as shown with Identity-H encoding.
+func (ff *fontFile) glyphIndexes(text string) []byte {
+	buf := make([]byte, 0, len(text)*2)
+	for _, r := range text {
+		gi := ff.font.GlyphIndex(r)
+		if gi < 0 {
+			gi = 0
+		}
+		buf = append(buf, byte(gi>>8), byte(gi))
+	}
+	return buf
+}
+
+func (ff *fontFile) glyphs() []int {
+	glyphs := make([]int, 0, len(ff.runes))
+	for r := range ff.runes {
+		if gi := ff.font.GlyphIndex(r); gi > 0 {
+			glyphs = append(glyphs, gi)
+		}
+	}
+	sort.Ints(glyphs)
+	return glyphs
+}
+
+// glyphRunes maps each glyph used to the rune printed with it.
+// Where several runes share a glyph, the lowest is chosen so that the mapping is deterministic.
+func (ff *fontFile) glyphRunes() map[int]rune {
+	runes := make(map[int]rune, len(ff.runes))
+	for r := range ff.runes {
+		if gi := ff.font.GlyphIndex(r); gi > 0 {
+			if prev, ok := runes[gi]; !ok || r < prev {
+				runes[gi] = r
+			}
+		}
+	}
+	return runes
+}
+
+// glyphWidths returns the widths of the glyphs used, scaled to 1000 units per em.
+func (ff *fontFile) glyphWidths() map[int]int {
+	widths := make(map[int]int, len(ff.runes))
+	upm := ff.font.UnitsPerEm()
+	// Avoid divide by zero error for unusual fonts.
+	if upm <= 0 {
+		return widths
+	}
+	for r := range ff.runes {
+		if gi := ff.font.GlyphIndex(r); gi > 0 {
+			designWidth, _ := ff.font.AdvanceWidth(r)
+			widths[gi] = designWidth * 1000 / upm
+		}
+	}
+	return widths
+}
+
+// cidWidths builds a W array for a CIDFont, grouping widths of consecutive glyphs as "c [w1 w2 ...]".
+func cidWidths(widths map[int]int) (w array) {
+	glyphs := make([]int, 0, len(widths))
+	for gi := range widths {
+		glyphs = append(glyphs, gi)
+	}
+	sort.Ints(glyphs)
+	for i := 0; i < len(glyphs); {
+		run := array{integer(widths[glyphs[i]])}
+		j := i + 1
+		for j < len(glyphs) && glyphs[j] == glyphs[j-1]+1 {
+			run = append(run, integer(widths[glyphs[j]]))
+			j++
+		}
+		w = append(w, integer(glyphs[i]), run)
+		i = j
+	}
+	return
+}
+
+// subsetTag derives a tag of six uppercase letters from a font name and object number,
+// distinguishing subsets within a document.
+func subsetTag(postScriptName string, seq int) string {
+	n := crc32.ChecksumIEEE([]byte(postScriptName)) + uint32(seq)
+	tag := make([]byte, 6)
+	for i := range tag {
+		tag[i] = byte('A' + n%26)
+		n /= 26
+	}
+	return string(tag)
+}
@@ -0,0 +1,144 @@
+// Copyright 2015 Brent Rowland.
+// Use of this source code is governed the Apache License, Version 2.0, as described in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/rowland/leadtype/afm_fonts"
+	"github.com/rowland/leadtype/font"
+	"github.com/rowland/leadtype/options"
+	"github.com/rowland/leadtype/ttf_fonts"
+)
+
+// restrictedFont poses as a TrueType font whose license forbids embedding, using the metrics of another font.
+type restrictedFont struct {
+	font.FontMetrics
+}
+
+func (restrictedFont) Embeddable() bool              { return false }
+func (restrictedFont) GlyphIndex(codepoint rune) int { return 0 }
+func (restrictedFont) Subset(io.Writer, []int) error { return nil }
+
+type restrictedFontSource struct {
+	font.FontSource
+}
+
+func (fs restrictedFontSource) Select(family, weight, style string, ranges []string) (font.FontMetrics, error) {
+	metrics, err := fs.FontSource.Select(family, weight, style, ranges)
+	return restrictedFont{metrics}, err
+}
+
+func (restrictedFontSource) SubType() string {
+	return "TrueType"
+}
+
+// restrictedFonts returns the first of the AFM fonts of family, posing as a TrueType font not licensed for embedding.
+func restrictedFonts(t *testing.T, family string) *font.Font {
+	fc, err := afm_fonts.New("../afm/data/fonts/*.afm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := font.New(family, options.Options{}, font.FontSources{restrictedFontSource{fc}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func TestCIDWidths(t *testing.T) {
+	w := cidWidths(map[int]int{3: 278, 36: 667, 37: 667, 38: 722, 68: 556})
+	expectS(t, "[3 [278 ] 36 [667 667 722 ] 68 [556 ] ] ", stringFromWriter(w))
+	expectS(t, "[] ", stringFromWriter(cidWidths(nil)))
+}
+
+func TestSubsetTag(t *testing.T) {
+	tag := subsetTag("ArialMT", 7)
+	expectI(t, 6, len(tag))
+	for _, c := range tag {
+		check(t, c >= 'A' && c <= 'Z', "Subset tag should consist of uppercase letters.")
+	}
+	expectS(t, tag, subsetTag("ArialMT", 7))
+	check(t, tag != subsetTag("ArialMT", 8), "Subset tags should differ by object number.")
+}
+
+func TestDocWriter_fontFile(t *testing.T) {
+	fc, err := ttf_fonts.New("/Library/Fonts/*.ttf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dw := NewDocWriter()
+	dw.AddFontSource(fc)
+	fonts, err := dw.AddFont("Arial", options.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ff := dw.fontFile(fonts[0])
+	checkFatal(t, ff != nil, "Arial should be embeddable.")
+	check(t, ff == dw.fontFile(fonts[0]), "Same font should yield same font file.")
+	expectS(t, ff.tag+"+ArialMT", ff.baseFont())
+
+	ff.addRunes("Abba")
+	expectI(t, 2, len(ff.glyphs()))
+	if err := ff.embed(); err != nil {
+		t.Fatal(err)
+	}
+	check(t, len(ff.stream.data) > 0, "Font file stream should contain subset font.")
+	expectI(t, len(ff.stream.data), int(ff.stream.dict["Length1"].(integer)))
+}
+
+func TestDocWriter_compositeFontKey(t *testing.T) {
+	fc, err := ttf_fonts.New("/Library/Fonts/*.ttf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dw := NewDocWriter()
+	dw.AddFontSource(fc)
+	fonts, err := dw.AddFont("Arial", options.Options{"composite": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(t, dw.isComposite(fonts[0]), "Font should be composite.")
+
+	key1, err := dw.compositeFontKey(fonts[0])
+	checkFatal(t, err == nil, "compositeFontKey should succeed.")
+	expectS(t, "F0", key1)
+	key2, _ := dw.compositeFontKey(fonts[0])
+	expectS(t, key1, key2)
+	ff := dw.fontFile(fonts[0])
+	checkFatal(t, ff.cidFont != nil, "Font file should reference CIDFont.")
+
+	gi := fonts[0].GlyphIndex('A')
+	expectS(t, string([]byte{byte(gi >> 8), byte(gi), 0, 0}), string(ff.glyphIndexes("A\U0010FFFF")))
+
+	ff.addRunes("A")
+	if err := ff.embed(); err != nil {
+		t.Fatal(err)
+	}
+	check(t, ff.cidFont.dict["W"] != nil, "CIDFont should have widths.")
+}
+
+func TestDocWriter_compositeFontKey_notEmbeddable(t *testing.T) {
+	f := restrictedFonts(t, "Helvetica")
+	dw := NewDocWriter()
+	_, err := dw.compositeFontKey(f)
+	checkFatal(t, err != nil, "compositeFontKey should fail for font not licensed for embedding.")
+	expectS(t, "Font Helvetica is not licensed for embedding and cannot be used as a composite font.", err.Error())
+}
+
+func TestPageWriter_Print_fallbackNotEmbeddable(t *testing.T) {
+	dw := NewDocWriter()
+	dw.SetCompositeFallback(true)
+	dw.NewPage()
+	dw.CurPage().addFont(restrictedFonts(t, "Helvetica"))
+	check(t, dw.Print("Hi \u4E2D") == nil, "Print should succeed.")
+	var buf bytes.Buffer
+	_, err := dw.WriteTo(&buf)
+	checkFatal(t, err == nil, "Text without a codepage should be omitted rather than fail.")
+	check(t, !strings.Contains(buf.String(), "/Type0"), "Font not licensed for embedding should not fall back to composite.")
+}
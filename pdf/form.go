@@ -0,0 +1,105 @@
+// Copyright 2015 Brent Rowland.
+// Use of this source code is governed the Apache License, Version 2.0, as described in the LICENSE file.
+
+package pdf
+
+import (
+	"fmt"
+)
+
+// Matrix transforms coordinates in points from x, y to A*x + C*y + E, B*x + D*y + F.
+type Matrix struct {
+	A, B, C, D, E, F float64
+}
+
+// Form is content, such as a page returned by ImportPage or a template returned by EndTemplate,
+// that can be printed on any page as often as needed. Its content and resources are stored in the document only once.
+type Form struct {
+	name          string
+	width, height float64
+	xObject       *formXObject
+}
+
+// Height returns the natural height of the form in points.
+func (f *Form) Height() float64 {
+	return f.height
+}
+
+// Width returns the natural width of the form in points.
+func (f *Form) Width() float64 {
+	return f.width
+}
+
+// size returns the dimensions at which to place the form, calculated as for images.
+func (f *Form) size(width, height float64, units *units) (float64, float64) {
+	switch {
+	case width == 0 && height == 0:
+		return units.fromPts(f.width), units.fromPts(f.height)
+	case width == 0:
+		return height * f.width / f.height, height
+	case height == 0:
+		return width, width * f.height / f.width
+	}
+	return width, height
+}
+
+// addForm adds xObject to the document's resources under a new name.
+func (dw *DocWriter) addForm(xObject *formXObject, width, height float64) *Form {
+	form := &Form{
+		name:    fmt.Sprintf("Fm%d", len(dw.forms)),
+		width:   width,
+		height:  height,
+		xObject: xObject,
+	}
+	dw.resources.setXObject(form.name, &indirectObjectRef{xObject})
+	dw.forms = append(dw.forms, form)
+	return form
+}
+
+// PrintForm places form with its top left corner at x, y, scaled to width and height.
+// If width or height is zero, it is calculated from the other to preserve the form's aspect ratio.
+// If both are zero, the form is printed at its natural size.
+func (pw *PageWriter) PrintForm(form *Form, x, y, width, height float64) {
+	width, height = form.size(width, height, pw.units)
+	xpts, ypts := pw.units.toPts(x), pw.translate(pw.units.toPts(y+height))
+	wpts, hpts := pw.units.toPts(width), pw.units.toPts(height)
+
+	pw.startGraph()
+	if pw.inPath && pw.autoPath {
+		pw.gw.stroke()
+		pw.inPath = false
+	}
+	pw.checkSetExtGState()
+	pw.gw.saveGraphicsState()
+	pw.gw.concatMatrix(wpts/form.width, 0, 0, hpts/form.height, xpts, ypts)
+	pw.mw.xObject(form.name)
+	pw.gw.restoreGraphicsState()
+	pw.MoveTo(x+width, y)
+}
+
+// StampForm paints form with its lower left corner at the lower left corner of the page,
+// transformed by matrix if it is not nil.
+func (pw *PageWriter) StampForm(form *Form, matrix *Matrix) {
+	pw.startGraph()
+	if pw.inPath && pw.autoPath {
+		pw.gw.stroke()
+		pw.inPath = false
+	}
+	pw.checkSetExtGState()
+	if matrix == nil {
+		pw.mw.xObject(form.name)
+		return
+	}
+	pw.gw.saveGraphicsState()
+	pw.gw.concatMatrix(matrix.A, matrix.B, matrix.C, matrix.D, matrix.E, matrix.F)
+	pw.mw.xObject(form.name)
+	pw.gw.restoreGraphicsState()
+}
+
+func (dw *DocWriter) PrintForm(form *Form, x, y, width, height float64) {
+	dw.CurPage().PrintForm(form, x, y, width, height)
+}
+
+func (dw *DocWriter) StampForm(form *Form, matrix *Matrix) {
+	dw.CurPage().StampForm(form, matrix)
+}
@@ -0,0 +1,160 @@
+// Copyright 2015 Brent Rowland.
+// Use of this source code is governed the Apache License, Version 2.0, as described in the LICENSE file.
+
+package pdf
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/rowland/leadtype/colors"
+)
+
+var errNoStops = errors.New("Need at least 1 stop for gradient.")
+
+// Gradient is a smooth blend of colours, which fills shapes in place of a single colour when passed to SetFillColor.
+// Its coordinates are in the units of the page it fills, measured down from the top, and it extends beyond its
+// ends in the colours of its first and last stops.
+type Gradient struct {
+	radial bool
+	coords []float64
+	stops  []colors.Stop
+}
+
+func newGradient(radial bool, coords []float64, stops []colors.Stop) (*Gradient, error) {
+	if len(stops) == 0 {
+		return nil, errNoStops
+	}
+	stops = append([]colors.Stop(nil), stops...)
+	sort.SliceStable(stops, func(i, j int) bool { return stops[i].Offset < stops[j].Offset })
+	return &Gradient{radial: radial, coords: coords, stops: stops}, nil
+}
+
+// NewLinearGradient returns a gradient blending from the colours at stops along the line from x1, y1 to x2, y2.
+func NewLinearGradient(x1, y1, x2, y2 float64, stops []colors.Stop) (*Gradient, error) {
+	return newGradient(false, []float64{x1, y1, x2, y2}, stops)
+}
+
+// NewRadialGradient returns a gradient blending from the colours at stops between the circle centred at x1, y1
+// with radius r1 and the circle centred at x2, y2 with radius r2.
+func NewRadialGradient(x1, y1, r1, x2, y2, r2 float64, stops []colors.Stop) (*Gradient, error) {
+	return newGradient(true, []float64{x1, y1, r1, x2, y2, r2}, stops)
+}
+
+// interpolation returns an exponential (type 2) function blending linearly from c0 to c1 across domain.
+func interpolation(c0, c1 colors.Color, domain array) dictionary {
+	r0, g0, b0 := c0.RGB64()
+	r1, g1, b1 := c1.RGB64()
+	return dictionary{
+		"FunctionType": integer(2),
+		"Domain":       domain,
+		"C0":           realArray(r0, g0, b0),
+		"C1":           realArray(r1, g1, b1),
+		"N":            integer(1),
+	}
+}
+
+// function returns a function mapping offsets along the gradient to colours: an exponential function for two stops,
+// or a stitching (type 3) function joining one for each pair of neighbouring stops.
+func (grad *Gradient) function() dictionary {
+	stops := grad.stops
+	if len(stops) == 1 {
+		stops = append(stops, stops[0])
+	}
+	first, last := stops[0].Offset, stops[len(stops)-1].Offset
+	if first >= last {
+		first, last = 0, 1
+	}
+	if len(stops) == 2 {
+		return interpolation(stops[0].Color, stops[1].Color, realArray(first, last))
+	}
+	var functions, bounds, encode array
+	for i := 1; i < len(stops); i++ {
+		functions = append(functions, interpolation(stops[i-1].Color, stops[i].Color, realArray(0, 1)))
+		encode = append(encode, real(0), real(1))
+		if i < len(stops)-1 {
+			bounds = append(bounds, real(stops[i].Offset))
+		}
+	}
+	return dictionary{
+		"FunctionType": integer(3),
+		"Domain":       realArray(first, last),
+		"Functions":    functions,
+		"Bounds":       bounds,
+		"Encode":       encode,
+	}
+}
+
+// shading returns an axial (type 2) or radial (type 3) shading dictionary for the gradient, with coords in points.
+func (grad *Gradient) shading(coords []float64) dictionary {
+	shadingType := 2
+	if grad.radial {
+		shadingType = 3
+	}
+	return dictionary{
+		"ShadingType": integer(shadingType),
+		"ColorSpace":  name("DeviceRGB"),
+		"Coords":      realArray(coords...),
+		"Function":    grad.function(),
+		"Extend":      array{boolean(true), boolean(true)},
+	}
+}
+
+// gradientPattern returns the name of a pattern painting grad on pw in its current coordinate system,
+// creating it on first use.
+func (pw *PageWriter) gradientPattern(grad *Gradient) string {
+	coords := make([]float64, len(grad.coords))
+	for i, c := range grad.coords {
+		coords[i] = pw.units.toPts(c)
+	}
+	// Flip the y coordinate of each point, which is followed by a radius in a radial gradient.
+	stride := 2
+	if grad.radial {
+		stride = 3
+	}
+	for i := 1; i < len(coords); i += stride {
+		coords[i] = pw.translate(coords[i])
+	}
+	key := fmt.Sprint("shading", grad.radial, coords, grad.stops, pw.ctm)
+	if name, ok := pw.dw.patterns[key]; ok {
+		return name
+	}
+	m := pw.ctm
+	pattern := newShadingPattern(pw.dw.nextSeq(), 0, grad.shading(coords), realArray(m.A, m.B, m.C, m.D, m.E, m.F))
+	pw.dw.file.body.add(pattern)
+	return pw.dw.addPattern(key, pattern)
+}
+
+// addPattern adds pattern to the document's resources under a new name, recorded under key.
+func (dw *DocWriter) addPattern(key string, pattern seqGen) string {
+	name := fmt.Sprintf("P%d", len(dw.patterns))
+	dw.resources.setPattern(name, &indirectObjectRef{pattern})
+	dw.patterns[key] = name
+	return name
+}
+
+func (pw *PageWriter) checkSetFillGradient() {
+	name := pw.gradientPattern(pw.fillGradient)
+	if name == pw.last.fillPatternName {
+		return
+	}
+	if pw.inPath && pw.autoPath {
+		pw.gw.stroke()
+		pw.inPath = false
+	}
+	pw.mw.setColorSpaceFill("Pattern")
+	pw.mw.setPatternFill(name, nil)
+	pw.last.fillPatternName = name
+	// The fill colour must be set again after the pattern.
+	pw.last.fillColor = nil
+}
+
+// FillGradient returns the gradient set by SetFillColor, or nil if filling with a single colour.
+func (pw *PageWriter) FillGradient() *Gradient {
+	return pw.fillGradient
+}
+
+func (dw *DocWriter) FillGradient() *Gradient {
+	return dw.CurPage().FillGradient()
+}
@@ -0,0 +1,79 @@
+// Copyright 2015 Brent Rowland.
+// Use of this source code is governed the Apache License, Version 2.0, as described in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/rowland/leadtype/colors"
+)
+
+func TestGradient_function(t *testing.T) {
+	_, err := NewLinearGradient(0, 0, 1, 1, nil)
+	check(t, err == errNoStops, "Gradient should need stops.")
+
+	grad, _ := NewLinearGradient(0, 0, 1, 1, []colors.Stop{{Offset: 1, Color: colors.Blue}, {Offset: 0, Color: colors.Red}})
+	var buf bytes.Buffer
+	grad.function().write(&buf)
+	expectS(t, "<<\n/C0 [1 0 0 ] \n/C1 [0 0 1 ] \n/Domain [0 1 ] \n/FunctionType 2 \n/N 1 \n>>\n", buf.String())
+
+	grad, _ = NewLinearGradient(0, 0, 1, 1, []colors.Stop{{Offset: 0, Color: colors.Red}, {Offset: 0.25, Color: colors.Lime}, {Offset: 1, Color: colors.Blue}})
+	f := grad.function()
+	expectI(t, 3, int(f["FunctionType"].(integer)))
+	expectI(t, 2, len(f["Functions"].(array)))
+	buf.Reset()
+	f["Bounds"].write(&buf)
+	expectS(t, "[0.25 ] ", buf.String())
+	buf.Reset()
+	f["Encode"].write(&buf)
+	expectS(t, "[0 1 0 1 ] ", buf.String())
+
+	grad, _ = NewRadialGradient(0, 0, 0, 0, 0, 1, []colors.Stop{{Offset: 0.5, Color: colors.Red}})
+	buf.Reset()
+	grad.function()["Domain"].write(&buf)
+	expectS(t, "[0 1 ] ", buf.String())
+}
+
+func TestDocWriter_SetFillColor_gradient(t *testing.T) {
+	dw := NewDocWriter()
+	dw.SetCompressionLevel(NoCompression)
+	dw.NewPage()
+	linear, _ := NewLinearGradient(72, 72, 216, 72, []colors.Stop{{Offset: 0, Color: colors.Red}, {Offset: 1, Color: colors.Blue}})
+	radial, _ := NewRadialGradient(144, 144, 0, 144, 144, 72, []colors.Stop{{Offset: 0, Color: colors.White}, {Offset: 1, Color: colors.Black}})
+	dw.SetFillColor(linear)
+	check(t, dw.FillGradient() == linear, "Fill gradient should be set.")
+	dw.Rectangle(72, 72, 144, 36, false, true)
+	dw.Rectangle(72, 108, 144, 36, false, true)
+	dw.SetFillColor(radial)
+	dw.Circle(144, 144, 72, true, true, false)
+	dw.SetFillColor(colors.Red)
+	check(t, dw.FillGradient() == nil, "Setting a colour should replace the gradient.")
+	dw.Rectangle(72, 216, 144, 36, false, true)
+	var buf bytes.Buffer
+	_, err := dw.WriteTo(&buf)
+	checkFatal(t, err == nil, "WriteTo should succeed.")
+	pdf := buf.String()
+	expectI(t, 1, strings.Count(pdf, "/Pattern cs\n/P0 scn\n"))
+	check(t, strings.Contains(pdf, "/Pattern cs\n/P1 scn\n"), "Radial gradient should be set.")
+	check(t, strings.Contains(pdf, "/ShadingType 2 "), "Linear gradient should be axial shading.")
+	check(t, strings.Contains(pdf, "/Coords [72 720 216 720 ] "), "Axial coordinates should be in points from the bottom.")
+	check(t, strings.Contains(pdf, "/ShadingType 3 "), "Radial gradient should be radial shading.")
+	check(t, strings.Contains(pdf, "/Coords [144 648 0 144 648 72 ] "), "Radial coordinates should be in points from the bottom.")
+	check(t, strings.Contains(pdf, "/Matrix [1 0 0 1 0 0 ] "), "Pattern should be untransformed.")
+	check(t, strings.Contains(pdf, "/Pattern <<\n/P0 "), "Pattern should be in resources.")
+	check(t, strings.Contains(pdf, "1 0 0 rg\n72 540 144 36 re\n"), "Colour should be set after gradient.")
+	checkXRefTable(t, pdf)
+}
+
+func TestPageWriter_gradientPattern(t *testing.T) {
+	dw := NewDocWriter()
+	pw := dw.NewPage()
+	grad, _ := NewLinearGradient(0, 0, 100, 0, []colors.Stop{{Offset: 0, Color: colors.Red}, {Offset: 1, Color: colors.Blue}})
+	expectS(t, "P0", pw.gradientPattern(grad))
+	expectS(t, "P0", pw.gradientPattern(grad))
+	pw.Translate(72, 72)
+	expectS(t, "P1", pw.gradientPattern(grad))
+}
@@ -90,6 +90,10 @@ func (gw *graphWriter) saveGraphicsState() {
 	fmt.Fprintf(gw.wr, "q\n")
 }
 
+func (gw *graphWriter) setExtGState(name string) {
+	fmt.Fprintf(gw.wr, "/%s gs\n", name)
+}
+
 func (gw *graphWriter) setFlatness(flatness int) {
 	fmt.Fprintf(gw.wr, "%d i\n", flatness)
 }
@@ -134,6 +134,13 @@ func TestGraphWriter_saveGraphicsState(t *testing.T) {
 	expectS(t, "q\n", buf.String())
 }
 
+func TestGraphWriter_setExtGState(t *testing.T) {
+	var buf bytes.Buffer
+	gw := newGraphWriter(&buf)
+	gw.setExtGState("GS0")
+	expectS(t, "/GS0 gs\n", buf.String())
+}
+
 func TestGraphWriter_setFlatness(t *testing.T) {
 	var buf bytes.Buffer
 	gw := newGraphWriter(&buf)
@@ -0,0 +1,249 @@
+// Copyright 2015 Brent Rowland.
+// Use of this source code is governed the Apache License, Version 2.0, as described in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/png"
+)
+
+var (
+	errInvalidJPEG      = errors.New("Invalid JPEG data.")
+	errUnsupportedImage = errors.New("Unsupported image format.")
+)
+
+var jpegSignature = []byte{0xFF, 0xD8}
+
+var jpegColorSpaces = map[int]string{1: "DeviceGray", 3: "DeviceRGB", 4: "DeviceCMYK"}
+
+// docImage records an image XObject added to the document, along with its resource name and size in pixels.
+type docImage struct {
+	name          string
+	width, height int
+	xObject       *imageXObject
+}
+
+// size returns the dimensions at which to place the image.
+// If either dimension is zero, it is derived from the other to preserve the aspect ratio.
+// If both are zero, the natural size of the image is used, taking one pixel to be one point.
+func (img *docImage) size(width, height float64, units *units) (float64, float64) {
+	switch {
+	case width == 0 && height == 0:
+		return units.fromPts(float64(img.width)), units.fromPts(float64(img.height))
+	case width == 0:
+		return height * float64(img.width) / float64(img.height), height
+	case height == 0:
+		return width, width * float64(img.height) / float64(img.width)
+	}
+	return width, height
+}
+
+// jpegInfo describes the frame of a baseline or progressive JPEG.
+type jpegInfo struct {
+	width, height    int
+	components       int
+	bitsPerComponent int
+	adobe            bool
+}
+
+// readJPEGInfo scans the markers preceding the image data for the frame header and any Adobe APP14 marker.
+func readJPEGInfo(data []byte) (info jpegInfo, err error) {
+	if !bytes.HasPrefix(data, jpegSignature) {
+		return info, errInvalidJPEG
+	}
+	for i := 2; i < len(data); {
+		if data[i] != 0xFF {
+			return info, errInvalidJPEG
+		}
+		for i < len(data) && data[i] == 0xFF {
+			i++
+		}
+		if i >= len(data) {
+			break
+		}
+		marker := data[i]
+		i++
+		if marker == 0x01 || (marker >= 0xD0 && marker <= 0xD8) {
+			continue
+		}
+		if marker == 0xD9 || marker == 0xDA || i+2 > len(data) {
+			break
+		}
+		length := int(binary.BigEndian.Uint16(data[i:]))
+		if length < 2 || i+length > len(data) {
+			return info, errInvalidJPEG
+		}
+		segment := data[i+2 : i+length]
+		i += length
+		switch {
+		case marker == 0xEE:
+			info.adobe = bytes.HasPrefix(segment, []byte("Adobe"))
+		case marker >= 0xC0 && marker <= 0xCF && marker != 0xC4 && marker != 0xC8 && marker != 0xCC:
+			if len(segment) < 6 {
+				return info, errInvalidJPEG
+			}
+			info.bitsPerComponent = int(segment[0])
+			info.height = int(binary.BigEndian.Uint16(segment[1:]))
+			info.width = int(binary.BigEndian.Uint16(segment[3:]))
+			info.components = int(segment[5])
+		}
+	}
+	if info.width == 0 || info.height == 0 {
+		return info, errInvalidJPEG
+	}
+	if _, ok := jpegColorSpaces[info.components]; !ok {
+		return info, fmt.Errorf("Unsupported number of JPEG components: %d.", info.components)
+	}
+	return info, nil
+}
+
+// newJPEGImage returns an image XObject containing data unchanged, to be decoded with DCTDecode.
+func newJPEGImage(seq, gen int, data []byte, info jpegInfo) *imageXObject {
+	img := newImageXObject(seq, gen, info.width, info.height, info.bitsPerComponent, name(jpegColorSpaces[info.components]), data)
+	img.setFilter("DCTDecode")
+	// Adobe applications write CMYK JPEGs with inverted values.
+	if info.components == 4 && info.adobe {
+		img.setDecode(arrayFromInts([]int{1, 0, 1, 0, 1, 0, 1, 0}))
+	}
+	return img
+}
+
+// image returns the image XObject for data, adding it to the document on first use.
+// JPEG data is embedded unchanged; PNG and GIF images are decoded and their samples compressed.
+// Identical images are stored only once.
+func (dw *DocWriter) image(data []byte) (*docImage, error) {
+	digest := fmt.Sprintf("%x", sha1.Sum(data))
+	if img, ok := dw.images[digest]; ok {
+		return img, nil
+	}
+	var xObject *imageXObject
+	var width, height int
+	if bytes.HasPrefix(data, jpegSignature) {
+		info, err := readJPEGInfo(data)
+		if err != nil {
+			return nil, err
+		}
+		if dw.pdfa && info.components == 4 {
+			return nil, errPDFACMYKImage
+		}
+		xObject = newJPEGImage(dw.nextSeq(), 0, data, info)
+		width, height = info.width, info.height
+	} else {
+		decoded, _, err := image.Decode(bytes.NewReader(data))
+		if err == image.ErrFormat {
+			return nil, errUnsupportedImage
+		}
+		if err != nil {
+			return nil, err
+		}
+		xObject = dw.decodedImage(decoded)
+		width, height = decoded.Bounds().Dx(), decoded.Bounds().Dy()
+	}
+	dw.compress(&xObject.stream)
+	dw.file.body.add(xObject)
+	img := &docImage{
+		name:    fmt.Sprintf("Im%d", len(dw.images)),
+		width:   width,
+		height:  height,
+		xObject: xObject,
+	}
+	dw.resources.setXObject(img.name, &indirectObjectRef{xObject})
+	dw.images[digest] = img
+	return img, nil
+}
+
+// decodedImage returns an image XObject with 8-bit samples for img.
+// Paletted images keep their palettes as Indexed colour spaces. Any transparency is split into a soft mask,
+// which is added to the document before the image.
+func (dw *DocWriter) decodedImage(img image.Image) *imageXObject {
+	var colorSpace writer
+	var samples, alpha []byte
+	switch img := img.(type) {
+	case *image.Paletted:
+		colorSpace, samples, alpha = palettedSamples(img)
+	case *image.Gray, *image.Gray16:
+		colorSpace, samples = name("DeviceGray"), graySamples(img)
+	default:
+		colorSpace, samples, alpha = rgbSamples(img)
+	}
+	bounds := img.Bounds()
+	var sMask *imageXObject
+	if alpha != nil {
+		sMask = newImageXObject(dw.nextSeq(), 0, bounds.Dx(), bounds.Dy(), 8, name("DeviceGray"), alpha)
+		dw.compress(&sMask.stream)
+		dw.file.body.add(sMask)
+	}
+	xObject := newImageXObject(dw.nextSeq(), 0, bounds.Dx(), bounds.Dy(), 8, colorSpace, samples)
+	if sMask != nil {
+		xObject.setSMask(sMask)
+	}
+	return xObject
+}
+
+// palettedSamples returns an Indexed colour space for the image's palette, along with its samples,
+// and its alpha channel if any palette entry is not opaque.
+func palettedSamples(img *image.Paletted) (colorSpace writer, samples, alpha []byte) {
+	lookup := make([]byte, 0, len(img.Palette)*3)
+	opaque := true
+	for _, c := range img.Palette {
+		nc := color.NRGBAModel.Convert(c).(color.NRGBA)
+		lookup = append(lookup, nc.R, nc.G, nc.B)
+		opaque = opaque && nc.A == 0xFF
+	}
+	bounds := img.Bounds()
+	samples = make([]byte, 0, bounds.Dx()*bounds.Dy())
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		i := img.PixOffset(bounds.Min.X, y)
+		samples = append(samples, img.Pix[i:i+bounds.Dx()]...)
+	}
+	if !opaque {
+		alpha = make([]byte, len(samples))
+		for i, index := range samples {
+			if int(index) < len(img.Palette) {
+				alpha[i] = color.NRGBAModel.Convert(img.Palette[index]).(color.NRGBA).A
+			}
+		}
+	}
+	colorSpace = array{name("Indexed"), name("DeviceRGB"), integer(len(img.Palette) - 1), hexStr(lookup)}
+	return
+}
+
+// graySamples returns 8-bit samples for a grayscale image, discarding the low byte of 16-bit samples.
+func graySamples(img image.Image) []byte {
+	bounds := img.Bounds()
+	samples := make([]byte, 0, bounds.Dx()*bounds.Dy())
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			samples = append(samples, color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y)
+		}
+	}
+	return samples
+}
+
+// rgbSamples returns 8-bit RGB samples for any image, along with its alpha channel if it is not opaque.
+func rgbSamples(img image.Image) (colorSpace writer, samples, alpha []byte) {
+	bounds := img.Bounds()
+	samples = make([]byte, 0, bounds.Dx()*bounds.Dy()*3)
+	alpha = make([]byte, 0, bounds.Dx()*bounds.Dy())
+	opaque := true
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			samples = append(samples, c.R, c.G, c.B)
+			alpha = append(alpha, c.A)
+			opaque = opaque && c.A == 0xFF
+		}
+	}
+	if opaque {
+		alpha = nil
+	}
+	return name("DeviceRGB"), samples, alpha
+}
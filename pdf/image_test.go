@@ -0,0 +1,190 @@
+// Copyright 2015 Brent Rowland.
+// Use of this source code is governed the Apache License, Version 2.0, as described in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func testJPEG(t *testing.T, width, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		img.Set(x, 0, color.RGBA{255, 0, 0, 255})
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// A 3x3 8-bit grayscale PNG with Adam7 interlacing, whose samples are 0, 20, 40, ... 160.
+var interlacedPNG = []byte{
+	0x89, 0x50, 0x4E, 0x47, 0x0D, 0x0A, 0x1A, 0x0A, 0x00, 0x00, 0x00, 0x0D, 0x49, 0x48, 0x44, 0x52,
+	0x00, 0x00, 0x00, 0x03, 0x00, 0x00, 0x00, 0x03, 0x08, 0x00, 0x00, 0x00, 0x01, 0x04, 0x44, 0xDA,
+	0xF5, 0x00, 0x00, 0x00, 0x17, 0x49, 0x44, 0x41, 0x54, 0x78, 0x9C, 0x63, 0x60, 0x60, 0xD0, 0x60,
+	0xA8, 0x58, 0xC0, 0x20, 0xC2, 0xD0, 0xC3, 0x60, 0x13, 0x90, 0x02, 0x00, 0x11, 0x3F, 0x02, 0xD1,
+	0xE4, 0x97, 0xBD, 0x2F, 0x00, 0x00, 0x00, 0x00, 0x49, 0x45, 0x4E, 0x44, 0xAE, 0x42, 0x60, 0x82,
+}
+
+// Markers for a 4-component frame preceded by an Adobe APP14 marker, as written by Photoshop for CMYK images.
+var adobeCMYKJPEG = []byte{
+	0xFF, 0xD8, // SOI
+	0xFF, 0xEE, 0x00, 0x0E, 'A', 'd', 'o', 'b', 'e', 0x00, 0x64, 0x00, 0x00, 0x00, 0x00, 0x02, // APP14
+	0xFF, 0xC0, 0x00, 0x14, 0x08, 0x00, 0x20, 0x00, 0x30, 0x04, // SOF0: 8 bits, 32 high, 48 wide, 4 components
+	0x01, 0x11, 0x00, 0x02, 0x11, 0x00, 0x03, 0x11, 0x00, 0x04, 0x11, 0x00,
+	0xFF, 0xD9, // EOI
+}
+
+func TestReadJPEGInfo(t *testing.T) {
+	info, err := readJPEGInfo(testJPEG(t, 40, 30))
+	checkFatal(t, err == nil, "readJPEGInfo should succeed")
+	expectI(t, 40, info.width)
+	expectI(t, 30, info.height)
+	expectI(t, 3, info.components)
+	expectI(t, 8, info.bitsPerComponent)
+	check(t, !info.adobe, "Encoded JPEG should not have Adobe marker.")
+
+	info, err = readJPEGInfo(adobeCMYKJPEG)
+	checkFatal(t, err == nil, "readJPEGInfo should succeed")
+	expectI(t, 48, info.width)
+	expectI(t, 32, info.height)
+	expectI(t, 4, info.components)
+	check(t, info.adobe, "JPEG should have Adobe marker.")
+
+	_, err = readJPEGInfo([]byte{0xFF, 0xD8, 0xFF, 0xD9})
+	check(t, err == errInvalidJPEG, "JPEG without frame should be invalid.")
+}
+
+func TestNewJPEGImage(t *testing.T) {
+	info, _ := readJPEGInfo(adobeCMYKJPEG)
+	img := newJPEGImage(1, 0, adobeCMYKJPEG, info)
+	var buf bytes.Buffer
+	img.writeHeader(&buf)
+	img.dict.write(&buf)
+	expected := "1 0 obj\n<<\n" +
+		"/BitsPerComponent 8 \n" +
+		"/ColorSpace /DeviceCMYK \n" +
+		"/Decode [1 0 1 0 1 0 1 0 ] \n" +
+		"/Filter /DCTDecode \n" +
+		"/Height 32 \n" +
+		"/Length 42 \n" +
+		"/Subtype /Image \n" +
+		"/Type /XObject \n" +
+		"/Width 48 \n" +
+		">>\n"
+	expectS(t, expected, buf.String())
+	check(t, bytes.Equal(img.data, adobeCMYKJPEG), "JPEG data should be unchanged.")
+}
+
+func TestDocImage_size(t *testing.T) {
+	img := &docImage{width: 200, height: 100}
+	units := UnitConversions["in"]
+	w, h := img.size(0, 0, units)
+	expectF(t, 200.0/72, w)
+	expectF(t, 100.0/72, h)
+	w, h = img.size(4, 0, units)
+	expectF(t, 4, w)
+	expectF(t, 2, h)
+	w, h = img.size(0, 1, units)
+	expectF(t, 2, w)
+	expectF(t, 1, h)
+	w, h = img.size(3, 3, units)
+	expectF(t, 3, w)
+	expectF(t, 3, h)
+}
+
+func TestPageWriter_PrintImage(t *testing.T) {
+	dw := NewDocWriter()
+	pw := dw.NewPage()
+	data := testJPEG(t, 40, 30)
+	err := pw.PrintImage(bytes.NewReader(data), 72, 72, 0, 30)
+	checkFatal(t, err == nil, "PrintImage should succeed")
+	expectS(t, "q\n40 0 0 30 72 690 cm\n/Im0 Do\nQ\n", pw.stream.String())
+
+	pw2 := dw.NewPage()
+	err = pw2.PrintImage(bytes.NewReader(data), 0, 0, 0, 0)
+	checkFatal(t, err == nil, "PrintImage should succeed")
+	expectI(t, 1, len(dw.images))
+	expectI(t, 1, len(dw.resources.xObjects))
+
+	err = pw2.PrintImage(bytes.NewReader([]byte("Not an image.")), 0, 0, 0, 0)
+	check(t, err == errUnsupportedImage, "PrintImage should reject unsupported formats.")
+}
+
+func TestDocWriter_image_PNG(t *testing.T) {
+	dw := NewDocWriter()
+	dw.SetCompressionLevel(NoCompression)
+	rgba := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	rgba.Set(0, 0, color.NRGBA{255, 0, 0, 255})
+	rgba.Set(1, 0, color.NRGBA{0, 0, 255, 128})
+	img, err := dw.image(encodePNG(t, rgba))
+	checkFatal(t, err == nil, "image should succeed")
+	expectI(t, 2, img.width)
+	expectI(t, 1, img.height)
+	expectS(t, "/DeviceRGB ", stringFromWriter(img.xObject.dict["ColorSpace"]))
+	check(t, bytes.Equal([]byte{255, 0, 0, 0, 0, 255}, img.xObject.data), "Samples should be RGB.")
+	sMask := img.xObject.dict["SMask"].(*indirectObjectRef).obj.(*imageXObject)
+	expectS(t, "/DeviceGray ", stringFromWriter(sMask.dict["ColorSpace"]))
+	check(t, bytes.Equal([]byte{255, 128}, sMask.data), "Soft mask should hold alpha.")
+}
+
+func TestDocWriter_image_PNG16(t *testing.T) {
+	dw := NewDocWriter()
+	dw.SetCompressionLevel(NoCompression)
+	rgba := image.NewRGBA64(image.Rect(0, 0, 1, 1))
+	rgba.Set(0, 0, color.RGBA64{0xFFFF, 0x8080, 0x0101, 0xFFFF})
+	img, err := dw.image(encodePNG(t, rgba))
+	checkFatal(t, err == nil, "image should succeed")
+	expectS(t, "8 ", stringFromWriter(img.xObject.dict["BitsPerComponent"]))
+	check(t, bytes.Equal([]byte{0xFF, 0x80, 0x01}, img.xObject.data), "Samples should be reduced to 8 bits.")
+	check(t, img.xObject.dict["SMask"] == nil, "Opaque image should have no soft mask.")
+}
+
+func TestDocWriter_image_interlacedPNG(t *testing.T) {
+	dw := NewDocWriter()
+	dw.SetCompressionLevel(NoCompression)
+	img, err := dw.image(interlacedPNG)
+	checkFatal(t, err == nil, "image should succeed")
+	expectS(t, "/DeviceGray ", stringFromWriter(img.xObject.dict["ColorSpace"]))
+	check(t, bytes.Equal([]byte{0, 20, 40, 60, 80, 100, 120, 140, 160}, img.xObject.data), "Samples should be deinterlaced.")
+}
+
+func TestDocWriter_image_GIF(t *testing.T) {
+	dw := NewDocWriter()
+	dw.SetCompressionLevel(NoCompression)
+	palette := color.Palette{color.RGBA{0, 0, 0, 0}, color.RGBA{255, 0, 0, 255}}
+	paletted := image.NewPaletted(image.Rect(0, 0, 3, 1), palette)
+	paletted.SetColorIndex(1, 0, 1)
+	var buf bytes.Buffer
+	checkFatal(t, gif.Encode(&buf, paletted, nil) == nil, "GIF should encode")
+	img, err := dw.image(buf.Bytes())
+	checkFatal(t, err == nil, "image should succeed")
+	expectS(t, "[/Indexed /DeviceRGB 1 <000000FF0000> ] ", stringFromWriter(img.xObject.dict["ColorSpace"]))
+	check(t, bytes.Equal([]byte{0, 1, 0}, img.xObject.data), "Samples should be palette indexes.")
+	sMask := img.xObject.dict["SMask"].(*indirectObjectRef).obj.(*imageXObject)
+	check(t, bytes.Equal([]byte{0, 255, 0}, sMask.data), "Soft mask should hold palette alpha.")
+	check(t, sMask.seq < img.xObject.seq, "Soft mask should precede image in body.")
+}
+
+func TestDocWriter_image_compressed(t *testing.T) {
+	dw := NewDocWriter()
+	img, err := dw.image(encodePNG(t, image.NewGray(image.Rect(0, 0, 16, 16))))
+	checkFatal(t, err == nil, "image should succeed")
+	expectS(t, "/FlateDecode ", stringFromWriter(img.xObject.dict["Filter"]))
+}
@@ -0,0 +1,195 @@
+// Copyright 2015 Brent Rowland.
+// Use of this source code is governed the Apache License, Version 2.0, as described in the LICENSE file.
+
+package pdf
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"io"
+
+	"github.com/rowland/leadtype/pdf/reader"
+)
+
+// importedFile records what ImportPage has copied from a PDF file, so that nothing is copied twice.
+type importedFile struct {
+	forms   map[int]*Form
+	objects map[reader.Reference]writer
+}
+
+// importer copies objects from a PDF file into the document.
+type importer struct {
+	dw  *DocWriter
+	r   *reader.Reader
+	src *importedFile
+}
+
+// ImportPage copies page pageNum, starting at 1, of the PDF file read by r into the document as a Form,
+// which can then be printed on any page, as letterhead for example.
+// The page's content is copied along with the fonts, images and other resources it uses; its annotations are not.
+// Importing a page again returns the same Form, and resources shared by pages of the same file are copied once.
+func (dw *DocWriter) ImportPage(r io.ReadSeeker, pageNum int) (*Form, error) {
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	h := sha1.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return nil, err
+	}
+	digest := fmt.Sprintf("%x", h.Sum(nil))
+	src, ok := dw.imports[digest]
+	if !ok {
+		src = &importedFile{forms: make(map[int]*Form), objects: make(map[reader.Reference]writer)}
+		dw.imports[digest] = src
+	}
+	if form, ok := src.forms[pageNum]; ok {
+		return form, nil
+	}
+	rd, err := reader.New(r)
+	if err != nil {
+		return nil, err
+	}
+	page, err := rd.Page(pageNum)
+	if err != nil {
+		return nil, err
+	}
+	content, err := page.Content()
+	if err != nil {
+		return nil, err
+	}
+	imp := &importer{dw: dw, r: rd, src: src}
+	resources, err := imp.copy(page.Resources)
+	if err != nil {
+		return nil, err
+	}
+	box := page.CropBox
+	bbox := &rectangle{box.X1, box.Y1, box.X2, box.Y2}
+	width, height := box.Width(), box.Height()
+	xObject := newFormXObject(dw.nextSeq(), 0, bbox, content)
+	xObject.setResources(resources)
+	// Move the lower left corner of the visible area to the origin, turning the page upright if it is displayed rotated.
+	switch (page.Rotate%360 + 360) % 360 {
+	case 90:
+		xObject.setMatrix(array{integer(0), integer(-1), integer(1), integer(0), real(0 - box.Y1), real(box.X2)})
+		width, height = height, width
+	case 180:
+		xObject.setMatrix(array{integer(-1), integer(0), integer(0), integer(-1), real(box.X2), real(box.Y2)})
+	case 270:
+		xObject.setMatrix(array{integer(0), integer(1), integer(-1), integer(0), real(box.Y2), real(0 - box.X1)})
+		width, height = height, width
+	default:
+		if box.X1 != 0 || box.Y1 != 0 {
+			xObject.setMatrix(array{integer(1), integer(0), integer(0), integer(1), real(0 - box.X1), real(0 - box.Y1)})
+		}
+	}
+	dw.compress(&xObject.stream)
+	dw.file.body.add(xObject)
+	form := dw.addForm(xObject, width, height)
+	src.forms[pageNum] = form
+	return form, nil
+}
+
+// copy converts obj from the reader's object model to the document's, copying and renumbering any indirect objects
+// it refers to.
+func (imp *importer) copy(obj reader.Object) (writer, error) {
+	switch o := obj.(type) {
+	case reader.Array:
+		a := make(array, len(o))
+		for i, elem := range o {
+			w, err := imp.copy(elem)
+			if err != nil {
+				return nil, err
+			}
+			a[i] = w
+		}
+		return a, nil
+	case reader.Boolean:
+		return boolean(o), nil
+	case reader.Dictionary:
+		d := make(dictionary, len(o))
+		if err := imp.copyEntries(d, o); err != nil {
+			return nil, err
+		}
+		return d, nil
+	case reader.Integer:
+		return integer(o), nil
+	case reader.Name:
+		return name(o), nil
+	case reader.Real:
+		return real(o), nil
+	case reader.Reference:
+		return imp.copyReference(o)
+	case reader.String:
+		return str(o), nil
+	}
+	return null{}, nil
+}
+
+// copyEntries copies the entries of src, other than those with keys in except, into dst in order of their keys,
+// so that copied objects are numbered predictably.
+func (imp *importer) copyEntries(dst dictionary, src reader.Dictionary, except ...string) error {
+	for _, key := range src.Keys() {
+		if containsKey(except, key) {
+			continue
+		}
+		w, err := imp.copy(src[key])
+		if err != nil {
+			return err
+		}
+		dst[key] = w
+	}
+	return nil
+}
+
+// copyReference copies the indirect object referred to by ref, unless it has been copied already.
+// References to the source document's page tree are dropped rather than pulling in its pages.
+func (imp *importer) copyReference(ref reader.Reference) (writer, error) {
+	if w, ok := imp.src.objects[ref]; ok {
+		return w, nil
+	}
+	obj, err := imp.r.Object(ref)
+	if err != nil {
+		return nil, err
+	}
+	dw := imp.dw
+	// Each copy is added to the document and recorded before its contents are copied, which numbers the objects
+	// in the order they are written and lets objects refer to each other in cycles.
+	switch o := obj.(type) {
+	case reader.Null:
+		return null{}, nil
+	case reader.Dictionary:
+		switch o.Name("Type") {
+		case "Catalog", "Page", "Pages":
+			return null{}, nil
+		}
+		d := newDictionaryObject(dw.nextSeq(), 0)
+		dw.file.body.add(d)
+		imp.src.objects[ref] = &indirectObjectRef{d}
+		return imp.src.objects[ref], imp.copyEntries(d.dict, o)
+	case *reader.Stream:
+		s := newStream(dw.nextSeq(), 0, o.Raw)
+		dw.file.body.add(s)
+		imp.src.objects[ref] = &indirectObjectRef{s}
+		// The copy keeps the Length of its own data, so the source's, which may be indirect, is not copied.
+		if err := imp.copyEntries(s.dict, o.Dict, "Length"); err != nil {
+			return nil, err
+		}
+		dw.compress(s)
+		return imp.src.objects[ref], nil
+	}
+	indObj := &indirectObject{}
+	indObj.init(dw.nextSeq(), 0, nil)
+	dw.file.body.add(indObj)
+	imp.src.objects[ref] = &indirectObjectRef{indObj}
+	indObj.obj, err = imp.copy(obj)
+	return imp.src.objects[ref], err
+}
+
+func containsKey(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
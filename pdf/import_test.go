@@ -0,0 +1,124 @@
+// Copyright 2015 Brent Rowland.
+// Use of this source code is governed the Apache License, Version 2.0, as described in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/rowland/leadtype/afm_fonts"
+	"github.com/rowland/leadtype/options"
+	"github.com/rowland/leadtype/pdf/reader"
+)
+
+// letterhead returns a two-page document, both pages printing in Helvetica, with the second page rotated.
+func letterhead(t *testing.T) *bytes.Reader {
+	fc, err := afm_fonts.New("../afm/data/fonts/*.afm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dw := NewDocWriter()
+	dw.AddFontSource(fc)
+	dw.NewPage()
+	dw.SetFont("Helvetica", 12, options.Options{})
+	dw.MoveTo(72, 72)
+	dw.Print("Letterhead")
+	dw.NewPageWithOptions(options.Options{"rotate": "landscape"})
+	dw.SetFont("Helvetica", 12, options.Options{})
+	dw.Print("Rotated")
+	var buf bytes.Buffer
+	if _, err = dw.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return bytes.NewReader(buf.Bytes())
+}
+
+func TestDocWriter_ImportPage(t *testing.T) {
+	src := letterhead(t)
+	dw := NewDocWriter()
+	dw.SetCompressionLevel(NoCompression)
+	form, err := dw.ImportPage(src, 1)
+	checkFatal(t, err == nil, "ImportPage should succeed.")
+	expectF(t, 612, form.Width())
+	expectF(t, 792, form.Height())
+	again, _ := dw.ImportPage(src, 1)
+	check(t, again == form, "Importing a page again should return the same form.")
+	rotated, err := dw.ImportPage(src, 2)
+	checkFatal(t, err == nil, "ImportPage should succeed for second page.")
+	expectF(t, 792, rotated.Width())
+	expectF(t, 612, rotated.Height())
+	_, err = dw.ImportPage(src, 3)
+	check(t, err != nil, "ImportPage should fail for page out of range.")
+
+	dw.NewPage()
+	dw.PrintForm(form, 0, 0, 0, 0)
+	dw.PrintForm(rotated, 72, 72, 396, 0)
+	var buf bytes.Buffer
+	_, err = dw.WriteTo(&buf)
+	checkFatal(t, err == nil, "WriteTo should succeed.")
+	pdf := buf.String()
+	check(t, strings.Contains(pdf, "1 0 0 1 0 0 cm\n/Fm0 Do\n"), "First form should be printed at natural size.")
+	check(t, strings.Contains(pdf, "0.5 0 0 0.5 72 414 cm\n/Fm1 Do\n"), "Second form should be scaled to width.")
+	check(t, strings.Contains(pdf, "/Matrix [0 1 -1 0 792 0 ] "), "Rotated page should be turned upright.")
+	expectI(t, 1, strings.Count(pdf, "/BaseFont /Helvetica "))
+
+	r, err := reader.New(bytes.NewReader(buf.Bytes()))
+	checkFatal(t, err == nil, "Output should be readable.")
+	page, err := r.Page(1)
+	checkFatal(t, err == nil, "Output should have a page.")
+	obj, _ := page.Resource("XObject", "Fm0")
+	xObject, ok := obj.(*reader.Stream)
+	checkFatal(t, ok, "Form should be a stream.")
+	expectS(t, "Form", string(xObject.Dict.Name("Subtype")))
+	content, _ := xObject.Decode()
+	check(t, strings.Contains(string(content), "(Letterhead) Tj"), "Form should contain the page's content.")
+	resources, _ := r.Dictionary(xObject.Dict["Resources"])
+	fonts, _ := r.Dictionary(resources["Font"])
+	f, _ := r.Dictionary(fonts["F0"])
+	expectS(t, "Helvetica", string(f.Name("BaseFont")))
+}
+
+// formPage returns a one-page document whose page draws a form, the length of which is an indirect object.
+func formPage() *bytes.Reader {
+	var buf bytes.Buffer
+	var offsets []int
+	obj := func(s string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), s)
+	}
+	buf.WriteString("%PDF-1.4\n")
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+	obj("<< /Type /Pages /Kids [3 0 R] /Count 1 >>")
+	obj("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R /Resources << /XObject << /X1 5 0 R >> >> >>")
+	obj("<< /Length 7 >>\nstream\n/X1 Do\n\nendstream")
+	obj("<< /Type /XObject /Subtype /Form /BBox [0 0 10 10] /Length 6 0 R >>\nstream\n0 0 m 10 10 l S\nendstream")
+	obj("15")
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f\r\n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n\r\n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return bytes.NewReader(buf.Bytes())
+}
+
+func TestDocWriter_ImportPage_streamLength(t *testing.T) {
+	dw := NewDocWriter()
+	dw.SetCompressionLevel(NoCompression)
+	_, err := dw.ImportPage(formPage(), 1)
+	checkFatal(t, err == nil, "ImportPage should succeed.")
+	for _, src := range dw.imports {
+		_, ok := src.objects[reader.Reference{Num: 6}]
+		check(t, !ok, "Length of stream should not be copied.")
+	}
+	dw.NewPage()
+	var buf bytes.Buffer
+	_, err = dw.WriteTo(&buf)
+	checkFatal(t, err == nil, "WriteTo should succeed.")
+	pdf := buf.String()
+	check(t, strings.Contains(pdf, "/Length 15 \n/Subtype /Form "), "Copied form should have the length of its data.")
+	check(t, !strings.Contains(pdf, "\n15\nendobj"), "Length of stream should not be copied.")
+}
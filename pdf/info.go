@@ -0,0 +1,159 @@
+// Copyright 2015 Brent Rowland.
+// Use of this source code is governed the Apache License, Version 2.0, as described in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/xml"
+	"fmt"
+	"time"
+	"unicode/utf16"
+)
+
+const defaultProducer = "leadtype"
+
+// Info holds the document information written to the Info dictionary and XMP metadata.
+// A zero CreationDate is replaced with the time the document is written.
+type Info struct {
+	Title        string
+	Author       string
+	Subject      string
+	Keywords     string
+	Creator      string
+	Producer     string
+	CreationDate time.Time
+	ModDate      time.Time
+}
+
+func (info *Info) dictionary() dictionary {
+	d := dictionary{}
+	setText := func(key, value string) {
+		if value != "" {
+			d[key] = textString(value)
+		}
+	}
+	setText("Title", info.Title)
+	setText("Author", info.Author)
+	setText("Subject", info.Subject)
+	setText("Keywords", info.Keywords)
+	setText("Creator", info.Creator)
+	setText("Producer", info.Producer)
+	if !info.CreationDate.IsZero() {
+		d["CreationDate"] = pdfDate(info.CreationDate)
+	}
+	if !info.ModDate.IsZero() {
+		d["ModDate"] = pdfDate(info.ModDate)
+	}
+	return d
+}
+
+// fileID derives an identifier for the document from its information and the number of objects it contains.
+func (info *Info) fileID(size int) []byte {
+	h := md5.New()
+	fmt.Fprintf(h, "%s\n%s\n%s\n%s\n%s\n%s\n%s\n%d",
+		info.Title, info.Author, info.Subject, info.Keywords, info.Creator, info.Producer,
+		info.CreationDate.Format(time.RFC3339Nano), size)
+	return h.Sum(nil)
+}
+
+const xmpHeader = "<?xpacket begin=\"\uFEFF\" id=\"W5M0MpCehiHzreSzNTczkc9d\"?>\n" +
+	"<x:xmpmeta xmlns:x=\"adobe:ns:meta/\">\n" +
+	"<rdf:RDF xmlns:rdf=\"http://www.w3.org/1999/02/22-rdf-syntax-ns#\">\n"
+
+const xmpFooter = `</rdf:RDF>
+</x:xmpmeta>
+<?xpacket end="w"?>`
+
+// xmp returns an XMP packet with the same information as the Info dictionary,
+// identifying the document as PDF/A-2b if pdfa is true.
+func (info *Info) xmp(pdfa bool) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(xmpHeader)
+
+	buf.WriteString("<rdf:Description rdf:about=\"\" xmlns:dc=\"http://purl.org/dc/elements/1.1/\">\n")
+	buf.WriteString("<dc:format>application/pdf</dc:format>\n")
+	if info.Title != "" {
+		fmt.Fprintf(&buf, "<dc:title><rdf:Alt><rdf:li xml:lang=\"x-default\">%s</rdf:li></rdf:Alt></dc:title>\n", xmlText(info.Title))
+	}
+	if info.Author != "" {
+		fmt.Fprintf(&buf, "<dc:creator><rdf:Seq><rdf:li>%s</rdf:li></rdf:Seq></dc:creator>\n", xmlText(info.Author))
+	}
+	if info.Subject != "" {
+		fmt.Fprintf(&buf, "<dc:description><rdf:Alt><rdf:li xml:lang=\"x-default\">%s</rdf:li></rdf:Alt></dc:description>\n", xmlText(info.Subject))
+	}
+	buf.WriteString("</rdf:Description>\n")
+
+	buf.WriteString("<rdf:Description rdf:about=\"\" xmlns:pdf=\"http://ns.adobe.com/pdf/1.3/\">\n")
+	if info.Keywords != "" {
+		fmt.Fprintf(&buf, "<pdf:Keywords>%s</pdf:Keywords>\n", xmlText(info.Keywords))
+	}
+	if info.Producer != "" {
+		fmt.Fprintf(&buf, "<pdf:Producer>%s</pdf:Producer>\n", xmlText(info.Producer))
+	}
+	buf.WriteString("</rdf:Description>\n")
+
+	buf.WriteString("<rdf:Description rdf:about=\"\" xmlns:xmp=\"http://ns.adobe.com/xap/1.0/\">\n")
+	if info.Creator != "" {
+		fmt.Fprintf(&buf, "<xmp:CreatorTool>%s</xmp:CreatorTool>\n", xmlText(info.Creator))
+	}
+	if !info.CreationDate.IsZero() {
+		fmt.Fprintf(&buf, "<xmp:CreateDate>%s</xmp:CreateDate>\n", info.CreationDate.Format(time.RFC3339))
+	}
+	if !info.ModDate.IsZero() {
+		fmt.Fprintf(&buf, "<xmp:ModifyDate>%s</xmp:ModifyDate>\n", info.ModDate.Format(time.RFC3339))
+	}
+	buf.WriteString("</rdf:Description>\n")
+
+	if pdfa {
+		buf.WriteString("<rdf:Description rdf:about=\"\" xmlns:pdfaid=\"http://www.aiim.org/pdfa/ns/id/\">\n")
+		buf.WriteString("<pdfaid:part>2</pdfaid:part>\n")
+		buf.WriteString("<pdfaid:conformance>B</pdfaid:conformance>\n")
+		buf.WriteString("</rdf:Description>\n")
+	}
+
+	buf.WriteString(xmpFooter)
+	return buf.Bytes()
+}
+
+// pdfDate formats t as a PDF date string, such as D:20150102150405-07'00'.
+func pdfDate(t time.Time) str {
+	date := t.Format("D:20060102150405")
+	_, offset := t.Zone()
+	if offset == 0 {
+		return str(date + "Z")
+	}
+	sign := '+'
+	if offset < 0 {
+		sign, offset = '-', -offset
+	}
+	return str(fmt.Sprintf("%s%c%02d'%02d'", date, sign, offset/3600, offset/60%60))
+}
+
+// textString encodes s as a PDF text string: ASCII strings are written as is,
+// while any others are written in UTF-16BE with a byte order mark.
+func textString(s string) str {
+	for _, r := range s {
+		if r > 0x7E {
+			return utf16String(s)
+		}
+	}
+	return str(s)
+}
+
+func utf16String(s string) str {
+	codes := utf16.Encode([]rune(s))
+	b := make([]byte, 2, 2+len(codes)*2)
+	b[0], b[1] = 0xFE, 0xFF
+	for _, c := range codes {
+		b = append(b, byte(c>>8), byte(c))
+	}
+	return str(b)
+}
+
+func xmlText(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
@@ -0,0 +1,81 @@
+// Copyright 2015 Brent Rowland.
+// Use of this source code is governed the Apache License, Version 2.0, as described in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPdfDate(t *testing.T) {
+	date := time.Date(2015, 1, 2, 15, 4, 5, 0, time.FixedZone("MST", -7*60*60))
+	expectS(t, "(D:20150102150405-07'00') ", stringFromWriter(pdfDate(date)))
+	date = time.Date(2015, 1, 2, 15, 4, 5, 0, time.FixedZone("IST", 5*60*60+30*60))
+	expectS(t, "(D:20150102150405+05'30') ", stringFromWriter(pdfDate(date)))
+	date = time.Date(2015, 1, 2, 15, 4, 5, 0, time.UTC)
+	expectS(t, "(D:20150102150405Z) ", stringFromWriter(pdfDate(date)))
+}
+
+func TestTextString(t *testing.T) {
+	expectS(t, "(Annual Report) ", stringFromWriter(textString("Annual Report")))
+	expectS(t, "\xFE\xFF\x00C\x00a\x00f\x00\xE9", string(textString("Café")))
+	expectS(t, "\xFE\xFF\xD8\x3D\xDE\x00", string(textString("\U0001F600")))
+}
+
+func TestInfo_dictionary(t *testing.T) {
+	info := Info{
+		Title:        "Report",
+		Author:       "Brent Rowland",
+		CreationDate: time.Date(2015, 1, 2, 15, 4, 5, 0, time.UTC),
+	}
+	expected := "<<\n" +
+		"/Author (Brent Rowland) \n" +
+		"/CreationDate (D:20150102150405Z) \n" +
+		"/Title (Report) \n" +
+		">>\n"
+	expectS(t, expected, stringFromWriter(info.dictionary()))
+}
+
+func TestInfo_xmp(t *testing.T) {
+	info := Info{
+		Title:        "Fish & Chips",
+		Author:       "Brent Rowland",
+		Keywords:     "food",
+		Producer:     "leadtype",
+		CreationDate: time.Date(2015, 1, 2, 15, 4, 5, 0, time.UTC),
+	}
+	xmp := string(info.xmp(false))
+	check(t, strings.HasPrefix(xmp, "<?xpacket begin=\"\uFEFF\""), "XMP should begin with xpacket.")
+	check(t, strings.HasSuffix(xmp, "<?xpacket end=\"w\"?>"), "XMP should end with xpacket.")
+	check(t, strings.Contains(xmp, "<rdf:li xml:lang=\"x-default\">Fish &amp; Chips</rdf:li>"), "XMP should contain escaped title.")
+	check(t, strings.Contains(xmp, "<dc:creator><rdf:Seq><rdf:li>Brent Rowland</rdf:li></rdf:Seq></dc:creator>"), "XMP should contain author.")
+	check(t, strings.Contains(xmp, "<pdf:Keywords>food</pdf:Keywords>"), "XMP should contain keywords.")
+	check(t, strings.Contains(xmp, "<pdf:Producer>leadtype</pdf:Producer>"), "XMP should contain producer.")
+	check(t, strings.Contains(xmp, "<xmp:CreateDate>2015-01-02T15:04:05Z</xmp:CreateDate>"), "XMP should contain creation date.")
+	check(t, !strings.Contains(xmp, "dc:description"), "XMP should omit empty subject.")
+	check(t, !strings.Contains(xmp, "pdfaid"), "XMP should not claim PDF/A conformance.")
+	xmp = string(info.xmp(true))
+	check(t, strings.Contains(xmp, "<pdfaid:part>2</pdfaid:part>\n<pdfaid:conformance>B</pdfaid:conformance>"), "XMP should identify PDF/A-2b.")
+}
+
+func TestDocWriter_SetInfo(t *testing.T) {
+	dw := NewDocWriter()
+	prev := dw.SetInfo(Info{Title: "Report"})
+	expectS(t, "", prev.Title)
+	expectS(t, "Report", dw.Info().Title)
+
+	var buf bytes.Buffer
+	dw.SetCompressionLevel(NoCompression)
+	dw.WriteTo(&buf)
+	pdf := buf.String()
+	check(t, strings.Contains(pdf, "/Title (Report) "), "Info should contain title.")
+	check(t, strings.Contains(pdf, "/Producer (leadtype) "), "Info should contain default producer.")
+	check(t, strings.Contains(pdf, "/CreationDate (D:"), "Info should contain default creation date.")
+	check(t, strings.Contains(pdf, "/Type /Metadata "), "Catalog should reference metadata.")
+	trailer := pdf[strings.LastIndex(pdf, "trailer"):]
+	check(t, strings.Contains(trailer, "/Info "), "Trailer should reference Info.")
+	check(t, strings.Contains(trailer, "/ID [<"), "Trailer should contain ID.")
+}
@@ -0,0 +1,152 @@
+// Copyright 2015 Brent Rowland.
+// Use of this source code is governed the Apache License, Version 2.0, as described in the LICENSE file.
+
+package pdf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// link records a link annotation to a page or named destination, resolved when the document is written.
+type link struct {
+	annot *annotation
+	page  int
+	y     float64
+	dest  string
+}
+
+// AddDestination names a position on the page, y units from the top, as the target of links.
+// If y is negative, the destination displays the whole page.
+func (pw *PageWriter) AddDestination(name string, y float64) {
+	if pw.page == nil {
+		return
+	}
+	pw.dw.dests[name] = pw.destination(y)
+}
+
+// AddDestLink makes a rectangle on the page a link to a destination named with AddDestination,
+// which may be on a page not yet written. Links are ignored on templates and patterns, which have no page to hold them.
+func (pw *PageWriter) AddDestLink(x, y, width, height float64, dest string) {
+	if annot := pw.addLinkAnnotation(pw.linkRect(x, y, width, height)); annot != nil {
+		pw.dw.links = append(pw.dw.links, &link{annot: annot, dest: dest})
+	}
+}
+
+// AddLink makes a rectangle on the page a link to a URI. Links are ignored on templates and patterns.
+func (pw *PageWriter) AddLink(x, y, width, height float64, uri string) {
+	if annot := pw.addLinkAnnotation(pw.linkRect(x, y, width, height)); annot != nil {
+		annot.setURI(uri)
+	}
+}
+
+// AddPageLink makes a rectangle on the page a link to a position on another page, top units from its top.
+// Page numbers start at 1 and may refer to pages not yet added. If top is negative, the link displays the whole page.
+// Links are ignored on templates and patterns.
+func (pw *PageWriter) AddPageLink(x, y, width, height float64, page int, top float64) {
+	if annot := pw.addLinkAnnotation(pw.linkRect(x, y, width, height)); annot != nil {
+		pw.dw.links = append(pw.dw.links, &link{annot: annot, page: page, y: top})
+	}
+}
+
+// addLinkAnnotation adds a link annotation covering rect to the page, returning nil on templates,
+// which have no page to hold annotations.
+func (pw *PageWriter) addLinkAnnotation(rect *rectangle) *annotation {
+	if pw.page == nil {
+		return nil
+	}
+	annot := newLinkAnnotation(pw.dw.nextSeq(), 0, rect)
+	pw.dw.file.body.add(annot)
+	pw.page.addAnnot(annot)
+	return annot
+}
+
+// addTextLink makes a rectangle, in points, a link to target: a destination name prefixed with "#" or a URI.
+func (pw *PageWriter) addTextLink(rect *rectangle, target string) {
+	annot := pw.addLinkAnnotation(rect)
+	if annot == nil {
+		return
+	}
+	if strings.HasPrefix(target, "#") {
+		pw.dw.links = append(pw.dw.links, &link{annot: annot, dest: target[1:]})
+	} else {
+		annot.setURI(target)
+	}
+}
+
+// pageTarget is what destinations on a page need of it: a reference to its page object,
+// and the height and units by which positions on it are measured.
+type pageTarget struct {
+	page   seqGen
+	height float64
+	units  *units
+}
+
+// destination returns an explicit destination for the page, scrolled to y units from the top,
+// or fitting the whole page if y is negative.
+func (pt pageTarget) destination(y float64) array {
+	if y < 0 {
+		return array{&indirectObjectRef{pt.page}, name("Fit")}
+	}
+	return array{&indirectObjectRef{pt.page}, name("XYZ"), null{}, real(pt.height - pt.units.toPts(y)), null{}}
+}
+
+// target returns the page's pageTarget, which does not hold on to the page's content.
+func (pw *PageWriter) target() pageTarget {
+	return pageTarget{refOnly(pw.page), pw.pageHeight, pw.units}
+}
+
+func (pw *PageWriter) destination(y float64) array {
+	return pw.target().destination(y)
+}
+
+// pageDestination returns a destination on the page at index i, scrolled to y, even if it has been released.
+func (dw *DocWriter) pageDestination(i int, y float64) array {
+	if i < len(dw.flushed) {
+		return dw.flushed[i].destination(y)
+	}
+	return dw.pages[i].destination(y)
+}
+
+// linkRect returns the rectangle on the page bounding the rectangle at x, y, width by height,
+// as transformed by the current coordinate system.
+func (pw *PageWriter) linkRect(x, y, width, height float64) *rectangle {
+	x1, y1 := pw.units.toPts(x), pw.translate(pw.units.toPts(y+height))
+	x2, y2 := pw.units.toPts(x+width), pw.translate(pw.units.toPts(y))
+	return pw.pageRect(Location{x1, y1}, Location{x2, y1}, Location{x2, y2}, Location{x1, y2})
+}
+
+func (dw *DocWriter) AddDestination(name string, y float64) {
+	dw.CurPage().AddDestination(name, y)
+}
+
+func (dw *DocWriter) AddDestLink(x, y, width, height float64, dest string) {
+	dw.CurPage().AddDestLink(x, y, width, height, dest)
+}
+
+func (dw *DocWriter) AddLink(x, y, width, height float64, uri string) {
+	dw.CurPage().AddLink(x, y, width, height, uri)
+}
+
+func (dw *DocWriter) AddPageLink(x, y, width, height float64, page int, top float64) {
+	dw.CurPage().AddPageLink(x, y, width, height, page, top)
+}
+
+// resolveLinks sets the destinations of links to pages and named destinations, now that all pages exist.
+func (dw *DocWriter) resolveLinks() error {
+	for _, l := range dw.links {
+		if l.dest != "" {
+			dest, ok := dw.dests[l.dest]
+			if !ok {
+				return fmt.Errorf("Link to undefined destination %s.", l.dest)
+			}
+			l.annot.setDest(dest)
+			continue
+		}
+		if l.page < 1 || l.page > len(dw.pages) {
+			return fmt.Errorf("Link page %d out of range 1-%d.", l.page, len(dw.pages))
+		}
+		l.annot.setDest(dw.pageDestination(l.page-1, l.y))
+	}
+	return nil
+}
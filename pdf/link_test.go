@@ -0,0 +1,125 @@
+// Copyright 2015 Brent Rowland.
+// Use of this source code is governed the Apache License, Version 2.0, as described in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/rowland/leadtype/afm_fonts"
+	"github.com/rowland/leadtype/options"
+	"github.com/rowland/leadtype/rich_text"
+)
+
+func TestLinkAnnotation(t *testing.T) {
+	a := newLinkAnnotation(1, 0, &rectangle{72, 700, 144, 720})
+	a.setURI("http://example.com/")
+	expected := "1 0 obj\n<<\n" +
+		"/A <<\n/S /URI \n/URI (http://example.com/) \n>>\n\n" +
+		"/Border [0 0 0 ] \n" +
+		"/F 4 \n" +
+		"/Rect [72 700 144 720 ] \n" +
+		"/Subtype /Link \n" +
+		"/Type /Annot \n" +
+		">>\nendobj\n"
+	expectS(t, expected, stringFromWriter(a))
+}
+
+func TestPageWriter_AddLink(t *testing.T) {
+	dw := NewDocWriter()
+	pw := dw.NewPageWithOptions(options.Options{"units": "in"})
+	pw.AddLink(1, 1, 2, 0.5, "http://example.com/")
+	annots := pw.page.dict["Annots"].(array)
+	expectI(t, 1, len(annots))
+	annot := annots[0].(*indirectObjectRef).obj.(*annotation)
+	expectS(t, "[72 684 216 720 ] ", stringFromWriter(annot.dict["Rect"]))
+	expectS(t, "(http://example.com/) ", stringFromWriter(annot.dict["A"].(dictionary)["URI"]))
+}
+
+func TestPageWriter_AddLink_transformed(t *testing.T) {
+	dw := NewDocWriter()
+	pw := dw.NewPage()
+	pw.Translate(72, 72)
+	pw.Scale(2, 2)
+	pw.AddLink(0, 0, 36, 18, "http://example.com/")
+	annot := pw.page.dict["Annots"].(array)[0].(*indirectObjectRef).obj.(*annotation)
+	expectS(t, "[72 684 144 720 ] ", stringFromWriter(annot.dict["Rect"]))
+
+	pw.Rotate(90)
+	pw.AddLink(0, 0, 36, 18, "http://example.com/")
+	annot = pw.page.dict["Annots"].(array)[1].(*indirectObjectRef).obj.(*annotation)
+	rect := annot.dict["Rect"].(*rectangle)
+	expectFdelta(t, 72, rect.x1, 0.001)
+	expectFdelta(t, 720, rect.y1, 0.001)
+	expectFdelta(t, 108, rect.x2, 0.001)
+	expectFdelta(t, 792, rect.y2, 0.001)
+}
+
+func TestPageWriter_AddLink_template(t *testing.T) {
+	dw := NewDocWriter()
+	dw.NewPage()
+	dw.BeginTemplate(100, 100)
+	dw.AddLink(0, 0, 10, 10, "http://example.com/")
+	dw.AddPageLink(0, 0, 10, 10, 1, 0)
+	dw.AddDestLink(0, 0, 10, 10, "summary")
+	dw.EndTemplate()
+	expectI(t, 0, len(dw.links))
+}
+
+func TestDocWriter_resolveLinks(t *testing.T) {
+	dw := NewDocWriter()
+	pw1 := dw.NewPage()
+	pw1.AddPageLink(0, 0, 10, 10, 2, 72)
+	pw1.AddDestLink(0, 20, 10, 10, "summary")
+	pw2 := dw.NewPage()
+	pw2.AddDestination("summary", -1)
+
+	var buf bytes.Buffer
+	_, err := dw.WriteTo(&buf)
+	checkFatal(t, err == nil, "WriteTo should succeed")
+	pageRef := stringFromWriter(&indirectObjectRef{pw2.page})
+	expectS(t, "["+pageRef+"/XYZ null 720 null ] ", stringFromWriter(dw.links[0].annot.dict["Dest"]))
+	expectS(t, "["+pageRef+"/Fit ] ", stringFromWriter(dw.links[1].annot.dict["Dest"]))
+
+	dw = NewDocWriter()
+	dw.AddDestLink(0, 0, 10, 10, "nowhere")
+	_, err = dw.WriteTo(&buf)
+	check(t, err != nil, "Link to undefined destination should fail.")
+
+	dw = NewDocWriter()
+	dw.AddPageLink(0, 0, 10, 10, 3, 0)
+	_, err = dw.WriteTo(&buf)
+	check(t, err != nil, "Link to missing page should fail.")
+}
+
+func TestPageWriter_flushText_link(t *testing.T) {
+	fc, err := afm_fonts.New("../afm/data/fonts/*.afm")
+	checkFatal(t, err == nil, "Failed to load AFM fonts.")
+	dw := NewDocWriter()
+	dw.AddFontSource(fc)
+	pw := dw.NewPage()
+	fonts, err := pw.SetFont("Helvetica", 10, options.Options{})
+	checkFatal(t, err == nil && len(fonts) == 1, "Failed to set Helvetica.")
+
+	rt, _ := rich_text.New("Go to ", fonts, 10, options.Options{})
+	rt, _ = rt.Add("example", fonts, 10, options.Options{"link": "http://example.com/"})
+	rt, _ = rt.Add(".com", fonts, 10, options.Options{"link": "http://example.com/", "underline": true})
+	rt, _ = rt.Add(" or the ", fonts, 10, options.Options{})
+	rt, _ = rt.Add("summary", fonts, 10, options.Options{"link": "#summary"})
+	pw.MoveTo(100, 100)
+	pw.PrintRichText(rt)
+	pw.flushText()
+
+	annots := pw.page.dict["Annots"].(array)
+	expectI(t, 2, len(annots))
+	uri := annots[0].(*indirectObjectRef).obj.(*annotation)
+	rect := uri.dict["Rect"].(*rectangle)
+	prefix, _ := rich_text.New("Go to ", fonts, 10, options.Options{})
+	linked, _ := rich_text.New("example.com", fonts, 10, options.Options{})
+	expectFdelta(t, 100+prefix.Width(), rect.x1, 0.001)
+	expectFdelta(t, 100+prefix.Width()+linked.Width(), rect.x2, 0.001)
+	check(t, rect.y1 < 692 && rect.y2 > 692, "Link should span the baseline.")
+	expectI(t, 1, len(dw.links))
+	expectS(t, "summary", dw.links[0].dest)
+}
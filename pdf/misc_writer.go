@@ -40,7 +40,7 @@ func (mw *miscWriter) setColorStroke(colors []float64) {
 	fmt.Fprintf(mw.wr, "%s SC\n", float64Slice(colors).join(" "))
 }
 
-// TODO: scn, SCN: patterns and separations
+// TODO: SCN: stroking patterns
 func (mw *miscWriter) setColorRenderingIntent(intent string) {
 	fmt.Fprintf(mw.wr, "/%s ri\n", intent)
 }
@@ -53,6 +53,14 @@ func (mw *miscWriter) setGrayStroke(gray float64) {
 	fmt.Fprintf(mw.wr, "%s G\n", g(gray))
 }
 
+// setPatternFill sets the pattern with which to fill, with the colour components of an uncoloured pattern.
+func (mw *miscWriter) setPatternFill(name string, colors []float64) {
+	if len(colors) > 0 {
+		fmt.Fprintf(mw.wr, "%s ", float64Slice(colors).join(" "))
+	}
+	fmt.Fprintf(mw.wr, "/%s scn\n", name)
+}
+
 func (mw *miscWriter) setRgbColorFill(red, green, blue float64) {
 	fmt.Fprintf(mw.wr, "%s %s %s rg\n", g(red), g(green), g(blue))
 }
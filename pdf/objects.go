@@ -5,11 +5,50 @@ package pdf
 
 import (
 	"bytes"
+	"compress/zlib"
 	"fmt"
 	"io"
 	"sort"
+	"strings"
 )
 
+// annotationPrint is the annotation flag for printing the annotation with the page.
+const annotationPrint = 4
+
+type annotation struct {
+	dictionaryObject
+}
+
+func (a *annotation) init(seq, gen int, subType string, rect *rectangle) *annotation {
+	a.dictionaryObject.init(seq, gen)
+	a.dict["Type"] = name("Annot")
+	a.dict["Subtype"] = name(subType)
+	a.dict["Rect"] = rect
+	return a
+}
+
+// newLinkAnnotation returns a link annotation, drawn without a border.
+// Its Print flag, required by PDF/A, has no effect on a link without a border.
+func newLinkAnnotation(seq, gen int, rect *rectangle) *annotation {
+	a := new(annotation).init(seq, gen, "Link", rect)
+	a.dict["Border"] = arrayFromInts([]int{0, 0, 0})
+	a.dict["F"] = integer(annotationPrint)
+	return a
+}
+
+// hasTarget reports whether the annotation has its destination or action, and so is complete.
+func (a *annotation) hasTarget() bool {
+	return a.dict["Dest"] != nil || a.dict["A"] != nil
+}
+
+func (a *annotation) setDest(dest array) {
+	a.dict["Dest"] = dest
+}
+
+func (a *annotation) setURI(uri string) {
+	a.dict["A"] = dictionary{"S": name("URI"), "URI": str(uri)}
+}
+
 type array []writer
 
 func (a array) write(w io.Writer) {
@@ -29,7 +68,8 @@ func arrayFromInts(ints []int) array {
 }
 
 type body struct {
-	list genWriterArray
+	list     genWriterArray
+	security *securityHandler
 }
 
 func (b *body) add(w ...genWriter) {
@@ -40,7 +80,26 @@ func (b *body) write(w lenWriter, ss *xRefSubSection) {
 	for _, e := range b.list {
 		xe := &inUseXRefEntry{w.Len(), e.Gen()}
 		ss.add(xe)
-		e.write(w)
+		b.writeObject(w, e)
+	}
+}
+
+// packable reports whether obj may be stored in an object stream.
+// Streams, objects with a nonzero generation and the Encrypt dictionary may not.
+func (b *body) packable(obj genWriter) bool {
+	if b.security != nil && obj == genWriter(b.security.dict) {
+		return false
+	}
+	p, ok := obj.(packer)
+	return ok && p.packable()
+}
+
+// writeObject writes obj, encrypting its strings and streams if the document is encrypted.
+func (b *body) writeObject(w io.Writer, obj genWriter) {
+	if b.security != nil {
+		obj.write(b.security.writer(w, obj))
+	} else {
+		obj.write(w)
 	}
 }
 
@@ -73,6 +132,105 @@ func newCatalog(seq, gen int, pageMode string, pages *pages, outlines *outlines)
 	return new(catalog).init(seq, gen, pageMode, pages, outlines)
 }
 
+func (c *catalog) setPageMode(pageMode string) {
+	c.pageMode = pageMode
+	c.dict["PageMode"] = name(pageMode)
+}
+
+// setExtensions declares the developer extensions used by the document, such as ADBE level 8 for AES-256 encryption.
+func (c *catalog) setExtensions(extensions dictionary) {
+	c.dict["Extensions"] = extensions
+}
+
+// setVersion overrides the version in the file header, which may have been written before the version was known.
+func (c *catalog) setVersion(version float32) {
+	c.dict["Version"] = name(fmt.Sprintf("%1.1f", version))
+}
+
+func (c *catalog) setMetadata(metadata *stream) {
+	c.dict["Metadata"] = &indirectObjectRef{metadata}
+}
+
+func (c *catalog) setOutputIntents(intents array) {
+	c.dict["OutputIntents"] = intents
+}
+
+// newOutputIntent returns an output intent of subtype s, describing the condition in which the document
+// is meant to be reproduced by its identifier and ICC profile.
+func newOutputIntent(s, identifier string, profile *stream) dictionary {
+	return dictionary{
+		"Type":                      name("OutputIntent"),
+		"S":                         name(s),
+		"OutputConditionIdentifier": str(identifier),
+		"Info":                      str(identifier),
+		"DestOutputProfile":         &indirectObjectRef{profile},
+	}
+}
+
+type cidFont struct {
+	dictionaryObject
+}
+
+func (f *cidFont) init(seq, gen int, subType, baseFont string, fontDescriptor *fontDescriptor) *cidFont {
+	f.dictionaryObject.init(seq, gen)
+	f.dict["Type"] = name("Font")
+	f.dict["Subtype"] = name(subType)
+	f.dict["BaseFont"] = name(baseFont)
+	f.dict["CIDSystemInfo"] = dictionary{
+		"Registry":   str("Adobe"),
+		"Ordering":   str("Identity"),
+		"Supplement": integer(0),
+	}
+	f.dict["FontDescriptor"] = &indirectObjectRef{fontDescriptor}
+	if subType == "CIDFontType2" {
+		f.dict["CIDToGIDMap"] = name("Identity")
+	}
+	return f
+}
+
+func newCIDFontType2(seq, gen int, baseFont string, fontDescriptor *fontDescriptor) *cidFont {
+	return new(cidFont).init(seq, gen, "CIDFontType2", baseFont, fontDescriptor)
+}
+
+func (f *cidFont) setWidths(widths array) {
+	f.dict["W"] = widths
+}
+
+// compressedXRefEntry locates an object by the number of the object stream containing it and its index there.
+type compressedXRefEntry struct {
+	objStm, index int
+}
+
+func (e *compressedXRefEntry) fields() (int, int, int) {
+	return 2, e.objStm, e.index
+}
+
+// countingWriter counts the bytes written through it, so the offsets of objects are known as they are written.
+// It latches the first error from the underlying writer: later writes are discarded and return that error,
+// so objects may be written without checking every write, and the error checked once at the end.
+type countingWriter struct {
+	w   io.Writer
+	n   int
+	err error
+}
+
+func (cw *countingWriter) Len() int {
+	return cw.n
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	if cw.err != nil {
+		return 0, cw.err
+	}
+	n, err := cw.w.Write(p)
+	cw.n += n
+	if err == nil && n < len(p) {
+		err = io.ErrShortWrite
+	}
+	cw.err = err
+	return n, err
+}
+
 type dictionary map[string]writer
 
 func (d dictionary) keys() []string {
@@ -117,28 +275,151 @@ func (d *dictionaryObject) write(w io.Writer) {
 	d.writeFooter(w)
 }
 
+// extGState is a graphics state parameter dictionary, used for transparency, which has no operators of its own.
+type extGState struct {
+	dictionaryObject
+}
+
+func newExtGState(seq, gen int, fillOpacity, strokeOpacity float64, blendMode string) *extGState {
+	gs := new(extGState)
+	gs.dictionaryObject.init(seq, gen)
+	gs.dict["Type"] = name("ExtGState")
+	gs.dict["ca"] = real(fillOpacity)
+	gs.dict["CA"] = real(strokeOpacity)
+	gs.dict["BM"] = name(blendMode)
+	return gs
+}
+
 type file struct {
-	header  header
-	body    body
-	trailer trailer
+	header        header
+	body          body
+	trailer       trailer
+	objectStreams bool
+	compression   int
+	out           *countingWriter // set by begin
+	entries       []xRefEntry     // indexed by object number, for objects written since begin
+	unflushed     int             // objects in the body before this index were added before the last flush
+	headerVersion float32         // the version written by begin
 }
 
 func newFile() *file {
-	return &file{header{}, body{}, trailer{dictionary{}, 0}}
+	return &file{header: header{}, body: body{}, trailer: trailer{dictionary{}, 0}}
 }
 
 func (f *file) write(w io.Writer) {
-	var buf bytes.Buffer // TODO: replace with io.Writer wrapper that implements lenWriter interface
+	f.writeTo(w)
+}
+
+// writeTo writes the whole file to w, returning the number of bytes written and the first error encountered.
+func (f *file) writeTo(w io.Writer) (int64, error) {
+	if f.objectStreams {
+		f.begin(w)
+		f.finish()
+		return int64(f.out.Len()), f.out.err
+	}
+	cw := &countingWriter{w: w}
 	var table xRefTable
 	ss := newXRefSubSection()
 	table.add(ss)
-	f.header.write(&buf)
-	f.body.write(&buf, ss)
-	f.trailer.xrefTableStart = buf.Len()
+	f.header.write(cw)
+	f.body.write(cw, ss)
+	f.trailer.xrefTableStart = cw.Len()
 	f.trailer.setXrefTableSize(ss.len())
-	table.write(&buf)
-	f.trailer.write(&buf)
-	buf.WriteTo(w)
+	table.write(cw)
+	f.trailer.write(cw)
+	return int64(cw.Len()), cw.err
+}
+
+// begin starts writing the file to w, for documents whose objects are written as they are finished.
+// The header is written immediately, so its version must already be settled.
+func (f *file) begin(w io.Writer) {
+	f.out = &countingWriter{w: w}
+	f.entries = []xRefEntry{&freeXRefEntry{0, 65535, nil}}
+	f.headerVersion = f.header.Version
+	f.header.write(f.out)
+}
+
+// flush writes objs, recording their offsets, and removes them from the body so they may be released.
+// Only objects added to the body since the last flush are sought.
+func (f *file) flush(objs ...genWriter) {
+	written := make(map[genWriter]bool, len(objs))
+	for _, obj := range objs {
+		f.setEntry(obj.Seq(), &inUseXRefEntry{f.out.Len(), obj.Gen()})
+		f.body.writeObject(f.out, obj)
+		written[obj] = true
+	}
+	list := f.body.list[:f.unflushed]
+	for _, e := range f.body.list[f.unflushed:] {
+		if !written[e] {
+			list = append(list, e)
+		}
+	}
+	for i := len(list); i < len(f.body.list); i++ {
+		f.body.list[i] = nil
+	}
+	f.body.list = list
+	f.unflushed = len(list)
+}
+
+// finish writes the objects remaining in the body, packing what it can into object streams if requested,
+// followed by the cross-reference table and trailer, or the cross-reference stream that replaces both.
+func (f *file) finish() {
+	var packed []genWriter
+	for _, e := range f.body.list {
+		if f.objectStreams && f.body.packable(e) {
+			packed = append(packed, e)
+			f.setEntry(e.Seq(), nil)
+			continue
+		}
+		f.setEntry(e.Seq(), &inUseXRefEntry{f.out.Len(), e.Gen()})
+		f.body.writeObject(f.out, e)
+	}
+	f.body.list = nil
+	if !f.objectStreams {
+		ss := newXRefSubSection()
+		for _, e := range f.entries[1:] {
+			ss.add(e.(writer))
+		}
+		var table xRefTable
+		table.add(ss)
+		f.trailer.xrefTableStart = f.out.Len()
+		f.trailer.setXrefTableSize(ss.len())
+		table.write(f.out)
+		f.trailer.write(f.out)
+		return
+	}
+	seq := len(f.entries) - 1
+	for len(packed) > 0 {
+		n := len(packed)
+		if n > maxObjectStreamObjects {
+			n = maxObjectStreamObjects
+		}
+		seq++
+		objStm := newObjectStream(seq, 0, packed[:n])
+		objStm.compress(f.compression)
+		for i, e := range packed[:n] {
+			f.setEntry(e.Seq(), &compressedXRefEntry{seq, i})
+		}
+		f.setEntry(seq, &inUseXRefEntry{f.out.Len(), 0})
+		f.body.writeObject(f.out, objStm)
+		packed = packed[n:]
+	}
+	seq++
+	f.trailer.xrefTableStart = f.out.Len()
+	f.setEntry(seq, &inUseXRefEntry{f.out.Len(), 0})
+	xRefStm := newXRefStream(seq, 0, f.trailer.dict, f.entries)
+	xRefStm.compress(f.compression)
+	// The cross-reference stream is never encrypted.
+	xRefStm.write(f.out)
+	f.trailer.writeStartXref(f.out)
+}
+
+// setEntry sets the cross-reference entry for object seq, leaving free entries for any numbers skipped.
+func (f *file) setEntry(seq int, e xRefEntry) {
+	for len(f.entries) <= seq {
+		f.entries = append(f.entries, &freeXRefEntry{0, 0, nil})
+	}
+	f.entries[seq] = e
 }
 
 type fontDescriptor struct {
@@ -190,6 +471,10 @@ func (fd *fontDescriptor) init(seq, gen int,
 	return fd
 }
 
+func (fd *fontDescriptor) setFontFile2(fontFile *stream) {
+	fd.dict["FontFile2"] = &indirectObjectRef{fontFile}
+}
+
 type fontEncoding struct {
 	dictionaryObject
 }
@@ -209,14 +494,45 @@ func (fe *fontEncoding) init(seq, gen int,
 	return fe
 }
 
+// formXObject is a self-contained description of content and the resources it uses, which can be painted any number of times.
+type formXObject struct {
+	stream
+}
+
+func (form *formXObject) init(seq, gen int, bbox *rectangle, data []byte) *formXObject {
+	form.stream.init(seq, gen, data)
+	form.dict["Type"] = name("XObject")
+	form.dict["Subtype"] = name("Form")
+	form.dict["FormType"] = integer(1)
+	form.dict["BBox"] = bbox
+	return form
+}
+
+func newFormXObject(seq, gen int, bbox *rectangle, data []byte) *formXObject {
+	return new(formXObject).init(seq, gen, bbox, data)
+}
+
+func (form *formXObject) setMatrix(matrix array) {
+	form.dict["Matrix"] = matrix
+}
+
+func (form *formXObject) setResources(resources writer) {
+	form.dict["Resources"] = resources
+}
+
 type freeXRefEntry indirectObject
 
+func (e *freeXRefEntry) fields() (int, int, int) {
+	return 0, e.seq, e.gen
+}
+
 func (e *freeXRefEntry) write(w io.Writer) {
 	fmt.Fprintf(w, "%.10d %.5d f\n", e.seq, e.gen)
 }
 
 type genWriter interface {
 	writer
+	Seq() int
 	Gen() int
 }
 
@@ -224,6 +540,7 @@ type genWriterArray []genWriter
 
 type header struct {
 	Version float32
+	binary  bool // followed by a comment of binary characters, marking the file as binary to transfer programs
 }
 
 func (h *header) write(w io.Writer) {
@@ -232,6 +549,52 @@ func (h *header) write(w io.Writer) {
 		v = 1.3
 	}
 	fmt.Fprintf(w, "%%PDF-%1.1f\n", v)
+	if h.binary {
+		fmt.Fprintf(w, "%%\xE2\xE3\xCF\xD3\n")
+	}
+}
+
+// requireVersion raises the version of the file to at least v.
+func (h *header) requireVersion(v float32) {
+	if h.Version < v {
+		h.Version = v
+	}
+}
+
+type hexStr []byte
+
+func (s hexStr) write(w io.Writer) {
+	if e, ok := w.(encrypter); ok {
+		s = e.encrypt(s)
+	}
+	fmt.Fprintf(w, "<%X> ", []byte(s))
+}
+
+type imageXObject struct {
+	stream
+}
+
+func (img *imageXObject) init(seq, gen int, width, height, bitsPerComponent int, colorSpace writer, data []byte) *imageXObject {
+	img.stream.init(seq, gen, data)
+	img.dict["Type"] = name("XObject")
+	img.dict["Subtype"] = name("Image")
+	img.dict["Width"] = integer(width)
+	img.dict["Height"] = integer(height)
+	img.dict["BitsPerComponent"] = integer(bitsPerComponent)
+	img.dict["ColorSpace"] = colorSpace
+	return img
+}
+
+func newImageXObject(seq, gen int, width, height, bitsPerComponent int, colorSpace writer, data []byte) *imageXObject {
+	return new(imageXObject).init(seq, gen, width, height, bitsPerComponent, colorSpace, data)
+}
+
+func (img *imageXObject) setDecode(decode array) {
+	img.dict["Decode"] = decode
+}
+
+func (img *imageXObject) setSMask(sMask *imageXObject) {
+	img.dict["SMask"] = &indirectObjectRef{sMask}
 }
 
 type indirectObject struct {
@@ -253,6 +616,15 @@ func (indObj *indirectObject) Seq() int {
 	return indObj.seq
 }
 
+// refOnly returns an object with the same number as obj, for references to obj that need not hold on to its content.
+func refOnly(obj seqGen) seqGen {
+	return &indirectObject{seq: obj.Seq(), gen: obj.Gen()}
+}
+
+func (indObj *indirectObject) packable() bool {
+	return indObj.gen == 0
+}
+
 func (indObj *indirectObject) write(w io.Writer) {
 	indObj.writeHeader(w)
 	if indObj.obj != nil {
@@ -288,6 +660,10 @@ type inUseXRefEntry struct {
 	byteOffset, gen int
 }
 
+func (e *inUseXRefEntry) fields() (int, int, int) {
+	return 1, e.byteOffset, e.gen
+}
+
 func (e *inUseXRefEntry) write(w io.Writer) {
 	fmt.Fprintf(w, "%.10d %.5d n\n", e.byteOffset, e.gen)
 }
@@ -300,7 +676,22 @@ type lenWriter interface {
 type name string
 
 func (n name) write(w io.Writer) {
-	fmt.Fprintf(w, "/%s ", n)
+	fmt.Fprintf(w, "/%s ", escapeName(string(n)))
+}
+
+// escapeName escapes the characters of s that may not appear literally in a name, such as spaces and delimiters,
+// as # followed by two hexadecimal digits.
+func escapeName(s string) string {
+	var buf bytes.Buffer
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < '!' || c > '~' || strings.IndexByte("#%()/<>[]{}", c) >= 0 {
+			fmt.Fprintf(&buf, "#%02X", c)
+		} else {
+			buf.WriteByte(c)
+		}
+	}
+	return buf.String()
 }
 
 func nameArray(names ...string) array {
@@ -311,6 +702,46 @@ func nameArray(names ...string) array {
 	return na
 }
 
+func realArray(values ...float64) array {
+	a := make(array, len(values))
+	for i, v := range values {
+		a[i] = real(v)
+	}
+	return a
+}
+
+// maxObjectStreamObjects limits the number of objects packed into each object stream,
+// so that readers need not decompress a large stream to reach any one object.
+const maxObjectStreamObjects = 100
+
+// newObjectStream returns a stream containing the bodies of objs, preceded by their numbers and offsets.
+func newObjectStream(seq, gen int, objs []genWriter) *stream {
+	var offsets, bodies bytes.Buffer
+	for _, obj := range objs {
+		fmt.Fprintf(&offsets, "%d %d ", obj.Seq(), bodies.Len())
+		bodies.Write(objectBody(obj))
+	}
+	s := newStream(seq, gen, append(offsets.Bytes(), bodies.Bytes()...))
+	s.dict["Type"] = name("ObjStm")
+	s.dict["N"] = integer(len(objs))
+	s.dict["First"] = integer(offsets.Len())
+	return s
+}
+
+// objectBody returns obj as written, less the "obj" and "endobj" keywords enclosing it.
+func objectBody(obj genWriter) []byte {
+	var buf bytes.Buffer
+	obj.write(&buf)
+	b := bytes.TrimPrefix(buf.Bytes(), []byte(fmt.Sprintf("%d %d obj\n", obj.Seq(), obj.Gen())))
+	return bytes.TrimSuffix(b, []byte("endobj\n"))
+}
+
+type null struct{}
+
+func (n null) write(w io.Writer) {
+	fmt.Fprintf(w, "null ")
+}
+
 type number struct {
 	value interface{}
 }
@@ -319,8 +750,93 @@ func (n number) write(w io.Writer) {
 	fmt.Fprintf(w, "%v ", n.value)
 }
 
+// outlineItem is an entry in the document outline. Its siblings and children are linked as they are added.
+type outlineItem struct {
+	dictionaryObject
+	open     bool
+	children []*outlineItem
+}
+
+func (item *outlineItem) init(seq, gen int, title writer, parent seqGen, dest array) *outlineItem {
+	item.dictionaryObject.init(seq, gen)
+	item.dict["Title"] = title
+	item.dict["Parent"] = &indirectObjectRef{parent}
+	item.dict["Dest"] = dest
+	return item
+}
+
+func newOutlineItem(seq, gen int, title writer, parent seqGen, dest array) *outlineItem {
+	return new(outlineItem).init(seq, gen, title, parent, dest)
+}
+
+func (item *outlineItem) add(child *outlineItem) {
+	item.children = linkOutlineItem(item.dict, item.children, child)
+}
+
+func (item *outlineItem) setColor(red, green, blue float64) {
+	item.dict["C"] = array{real(red), real(green), real(blue)}
+}
+
+// setStyle sets the flags for italic (1) and bold (2) text.
+func (item *outlineItem) setStyle(bold, italic bool) {
+	var flags int
+	if italic {
+		flags |= 1
+	}
+	if bold {
+		flags |= 2
+	}
+	if flags == 0 {
+		delete(item.dict, "F")
+	} else {
+		item.dict["F"] = integer(flags)
+	}
+}
+
+// visibleDescendants returns the number of descendants that would be visible if the item were open.
+func (item *outlineItem) visibleDescendants() int {
+	return visibleOutlineItems(item.children)
+}
+
+// Count is negative for a closed item, giving the number of descendants that would be visible were it open.
+func (item *outlineItem) write(w io.Writer) {
+	if len(item.children) > 0 {
+		count := item.visibleDescendants()
+		if !item.open {
+			count = -count
+		}
+		item.dict["Count"] = integer(count)
+	}
+	item.dictionaryObject.write(w)
+}
+
+// linkOutlineItem appends child to items, updating the First and Last entries of the parent's dictionary
+// and the Prev and Next entries of the siblings.
+func linkOutlineItem(parent dictionary, items []*outlineItem, child *outlineItem) []*outlineItem {
+	if len(items) == 0 {
+		parent["First"] = &indirectObjectRef{child}
+	} else {
+		last := items[len(items)-1]
+		last.dict["Next"] = &indirectObjectRef{child}
+		child.dict["Prev"] = &indirectObjectRef{last}
+	}
+	parent["Last"] = &indirectObjectRef{child}
+	return append(items, child)
+}
+
+func visibleOutlineItems(items []*outlineItem) (count int) {
+	for _, item := range items {
+		count++
+		if item.open {
+			count += item.visibleDescendants()
+		}
+	}
+	return
+}
+
 type outlines struct {
 	dictionaryObject
+	items []*outlineItem
 }
 
 func (o *outlines) init(seq, gen int) *outlines {
@@ -333,11 +849,20 @@ func newOutlines(seq, gen int) *outlines {
 	return new(outlines).init(seq, gen)
 }
 
+func (o *outlines) add(item *outlineItem) {
+	o.items = linkOutlineItem(o.dict, o.items, item)
+}
+
 func (o *outlines) write(w io.Writer) {
-	o.dict["Count"] = integer(0)
+	o.dict["Count"] = integer(visibleOutlineItems(o.items))
 	o.dictionaryObject.write(w)
 }
 
+// packer is implemented by objects that know whether they may be stored in an object stream.
+type packer interface {
+	packable() bool
+}
+
 type page struct {
 	pageBase
 	contents []*stream
@@ -357,6 +882,17 @@ func (p *page) add(s *stream) {
 	p.contents = append(p.contents, s)
 }
 
+// annotations returns the annotations added with addAnnot.
+func (p *page) annotations() (annots []*annotation) {
+	refs, _ := p.dict["Annots"].(array)
+	for _, ref := range refs {
+		if annot, ok := ref.(*indirectObjectRef).obj.(*annotation); ok {
+			annots = append(annots, annot)
+		}
+	}
+	return
+}
+
 func (p *page) contentLength() (result int) {
 	for _, s := range p.contents {
 		result += s.len()
@@ -372,7 +908,11 @@ func (p *page) contentRefs() (result array) {
 	return
 }
 
-// TODO: setAnnots
+func (p *page) addAnnot(annot seqGen) {
+	annots, _ := p.dict["Annots"].(array)
+	p.dict["Annots"] = append(annots, &indirectObjectRef{annot})
+}
+
 // TODO: setBeads
 
 func (p *page) setThumb(thumb seqGen) {
@@ -437,7 +977,7 @@ func (pb *pageBase) setRotate(rotate int) {
 
 type pages struct {
 	pageBase
-	kids []*page
+	kids []seqGen
 }
 
 func (ps *pages) init(seq, gen int) *pages {
@@ -454,6 +994,17 @@ func (ps *pages) add(p *page) {
 	ps.kids = append(ps.kids, p)
 }
 
+// release replaces p, once written, with a bare reference to it, so that its content can be freed.
+// Pages are released in the order added, so p is sought from the end.
+func (ps *pages) release(p *page) {
+	for i := len(ps.kids) - 1; i >= 0; i-- {
+		if ps.kids[i] == p {
+			ps.kids[i] = refOnly(p)
+			return
+		}
+	}
+}
+
 func (ps *pages) write(w io.Writer) {
 	ps.dict["Count"] = integer(len(ps.kids))
 	kidsRefs := make(array, len(ps.kids))
@@ -485,8 +1036,11 @@ func (r *rectangle) write(w io.Writer) {
 
 type resources struct {
 	dictionaryObject
-	fonts    dictionary
-	xObjects dictionary
+	colorSpaces dictionary
+	extGStates  dictionary
+	fonts       dictionary
+	patterns    dictionary
+	xObjects    dictionary
 }
 
 func (r *resources) init(seq, gen int) *resources {
@@ -500,6 +1054,22 @@ func newResources(seq, gen int) *resources {
 	return new(resources).init(seq, gen)
 }
 
+func (r *resources) setColorSpace(name string, colorSpace writer) {
+	if r.colorSpaces == nil {
+		r.colorSpaces = dictionary{}
+		r.dict["ColorSpace"] = r.colorSpaces
+	}
+	r.colorSpaces[name] = colorSpace
+}
+
+func (r *resources) setExtGState(name string, ref *indirectObjectRef) {
+	if r.extGStates == nil {
+		r.extGStates = dictionary{}
+		r.dict["ExtGState"] = r.extGStates
+	}
+	r.extGStates[name] = ref
+}
+
 func (r *resources) setFont(name string, ref *indirectObjectRef) {
 	if r.fonts == nil {
 		r.fonts = dictionary{}
@@ -508,6 +1078,14 @@ func (r *resources) setFont(name string, ref *indirectObjectRef) {
 	r.fonts[name] = ref
 }
 
+func (r *resources) setPattern(name string, ref *indirectObjectRef) {
+	if r.patterns == nil {
+		r.patterns = dictionary{}
+		r.dict["Pattern"] = r.patterns
+	}
+	r.patterns[name] = ref
+}
+
 func (r *resources) setProcSet(w writer) {
 	r.dict["ProcSet"] = w
 }
@@ -520,6 +1098,55 @@ func (r *resources) setXObject(name string, ref *indirectObjectRef) {
 	r.xObjects[name] = ref
 }
 
+// shadingPattern paints a shading, such as a gradient, wherever an area is filled with it.
+type shadingPattern struct {
+	dictionaryObject
+}
+
+// newShadingPattern returns a pattern painting shading, whose coordinates are mapped by matrix
+// to the default coordinate space of the content using it.
+func newShadingPattern(seq, gen int, shading dictionary, matrix array) *shadingPattern {
+	p := new(shadingPattern)
+	p.dictionaryObject.init(seq, gen)
+	p.dict["Type"] = name("Pattern")
+	p.dict["PatternType"] = integer(2)
+	p.dict["Shading"] = shading
+	p.dict["Matrix"] = matrix
+	return p
+}
+
+// tilingPattern paints copies of a cell of content side by side wherever an area is filled with it.
+type tilingPattern struct {
+	stream
+}
+
+// newTilingPattern returns a pattern repeating the content in data, which paints its own colours if colored is true,
+// or otherwise the colour it is filled with. Copies are placed at the size of bbox apart.
+func newTilingPattern(seq, gen int, colored bool, bbox *rectangle, data []byte) *tilingPattern {
+	p := new(tilingPattern)
+	p.stream.init(seq, gen, data)
+	paintType := 2
+	if colored {
+		paintType = 1
+	}
+	p.dict["Type"] = name("Pattern")
+	p.dict["PatternType"] = integer(1)
+	p.dict["PaintType"] = integer(paintType)
+	p.dict["TilingType"] = integer(1)
+	p.dict["BBox"] = bbox
+	p.dict["XStep"] = real(bbox.x2 - bbox.x1)
+	p.dict["YStep"] = real(bbox.y2 - bbox.y1)
+	return p
+}
+
+func (p *tilingPattern) setMatrix(matrix array) {
+	p.dict["Matrix"] = matrix
+}
+
+func (p *tilingPattern) setResources(resources writer) {
+	p.dict["Resources"] = resources
+}
+
 type seqGen interface {
 	Seq() int
 	Gen() int
@@ -546,7 +1173,6 @@ func (f *simpleFont) init(seq, gen int,
 	if fontEncoding != nil {
 		f.dict["Encoding"] = fontEncoding
 	}
-	// TODO: ToUnicode
 	return f
 }
 
@@ -560,6 +1186,10 @@ func newSimpleFont(seq, gen int,
 	return new(simpleFont).init(seq, gen, subType, baseFont, firstChar, lastChar, widths, fontDescriptor, fontEncoding)
 }
 
+func (f *simpleFont) setToUnicode(toUnicode *stream) {
+	f.dict["ToUnicode"] = &indirectObjectRef{toUnicode}
+}
+
 func newTrueTypeFont(seq, gen int,
 	baseFont string,
 	firstChar, lastChar int,
@@ -587,16 +1217,24 @@ var (
 	escapedLeftParen  = []byte("\\(")
 	rightParen        = []byte(")")
 	escapedRightParen = []byte("\\)")
+	carriageReturn    = []byte("\r")
+	escapedReturn     = []byte("\\r")
 )
 
 func (s str) escape() []byte {
 	s1 := bytes.Replace(s, backSlash, escapedBackslash, -1)
 	s2 := bytes.Replace(s1, leftParen, escapedLeftParen, -1)
 	s3 := bytes.Replace(s2, rightParen, escapedRightParen, -1)
-	return s3
+	// Readers would otherwise convert a literal carriage return to a line feed.
+	s4 := bytes.Replace(s3, carriageReturn, escapedReturn, -1)
+	return s4
 }
 
 func (s str) write(w io.Writer) {
+	if e, ok := w.(encrypter); ok {
+		fmt.Fprintf(w, "<%X> ", e.encrypt(s))
+		return
+	}
 	fmt.Fprintf(w, "(%s) ", s.escape())
 }
 
@@ -617,10 +1255,50 @@ func newStream(seq, gen int, data []byte) *stream {
 	return new(stream).init(seq, gen, data)
 }
 
+func newMetadataStream(seq, gen int, data []byte) *stream {
+	s := newStream(seq, gen, data)
+	s.dict["Type"] = name("Metadata")
+	s.dict["Subtype"] = name("XML")
+	return s
+}
+
+// newICCProfileStream returns a stream containing an ICC profile for a colour space of n components.
+func newICCProfileStream(seq, gen, n int, data []byte) *stream {
+	s := newStream(seq, gen, data)
+	s.dict["N"] = integer(n)
+	return s
+}
+
+// compress replaces the stream's data with its zlib encoding at the given level and sets the FlateDecode filter.
+// Streams that already have a filter, and all streams when level is NoCompression, are left as is.
+func (s *stream) compress(level int) error {
+	if level == NoCompression || s.dict["Filter"] != nil {
+		return nil
+	}
+	var buf bytes.Buffer
+	zw, err := zlib.NewWriterLevel(&buf, level)
+	if err != nil {
+		return err
+	}
+	if _, err = zw.Write(s.data); err != nil {
+		return err
+	}
+	if err = zw.Close(); err != nil {
+		return err
+	}
+	s.data = buf.Bytes()
+	s.setFilter("FlateDecode")
+	return nil
+}
+
 func (s *stream) len() int {
 	return len(s.data)
 }
 
+func (s *stream) packable() bool {
+	return false
+}
+
 func (s *stream) setFilter(filter string) {
 	s.dict["Filter"] = name(filter)
 }
@@ -632,13 +1310,18 @@ func (s *stream) write(w io.Writer) {
 }
 
 func (s *stream) writeBody(w io.Writer) {
-	s.dict["Length"] = integer(len(s.data))
+	data := s.data
+	if e, ok := w.(encrypter); ok && data != nil {
+		data = e.encrypt(data)
+	}
+	s.dict["Length"] = integer(len(data))
 	s.dict.write(w)
 	fmt.Fprintf(w, "stream\n")
-	if s.data != nil {
-		w.Write(s.data)
+	if data != nil {
+		w.Write(data)
 	}
-	fmt.Fprintf(w, "endstream\n")
+	// PDF/A requires an end-of-line marker before endstream, which other readers also accept.
+	fmt.Fprintf(w, "\nendstream\n")
 }
 
 type trailer struct {
@@ -650,6 +1333,18 @@ func newTrailer() *trailer {
 	return &trailer{dictionary{}, 0}
 }
 
+func (tr *trailer) setEncrypt(encrypt seqGen) {
+	tr.dict["Encrypt"] = &indirectObjectRef{encrypt}
+}
+
+func (tr *trailer) setID(id []byte) {
+	tr.dict["ID"] = array{hexStr(id), hexStr(id)}
+}
+
+func (tr *trailer) setInfo(info seqGen) {
+	tr.dict["Info"] = &indirectObjectRef{info}
+}
+
 func (tr *trailer) setRoot(root seqGen) {
 	tr.dict["Root"] = &indirectObjectRef{root}
 }
@@ -668,15 +1363,65 @@ func (tr *trailer) xrefTableSize() int {
 func (tr *trailer) write(w io.Writer) {
 	fmt.Fprintf(w, "trailer\n")
 	tr.dict.write(w)
+	tr.writeStartXref(w)
+}
+
+func (tr *trailer) writeStartXref(w io.Writer) {
 	fmt.Fprintf(w, "startxref\n")
 	fmt.Fprintf(w, "%d\n", tr.xrefTableStart)
 	fmt.Fprintf(w, "%%%%EOF\n")
 }
 
+type type0Font struct {
+	dictionaryObject
+}
+
+func (f *type0Font) init(seq, gen int, baseFont, encoding string, descendantFont *cidFont) *type0Font {
+	f.dictionaryObject.init(seq, gen)
+	f.dict["Type"] = name("Font")
+	f.dict["Subtype"] = name("Type0")
+	f.dict["BaseFont"] = name(baseFont)
+	f.dict["Encoding"] = name(encoding)
+	f.dict["DescendantFonts"] = array{&indirectObjectRef{descendantFont}}
+	return f
+}
+
+func newType0Font(seq, gen int, baseFont, encoding string, descendantFont *cidFont) *type0Font {
+	return new(type0Font).init(seq, gen, baseFont, encoding, descendantFont)
+}
+
+func (f *type0Font) setToUnicode(toUnicode *stream) {
+	f.dict["ToUnicode"] = &indirectObjectRef{toUnicode}
+}
+
 type writer interface {
 	write(io.Writer)
 }
 
+// xRefEntry is implemented by cross-reference entries, which are written to cross-reference streams
+// as a type and two fields.
+type xRefEntry interface {
+	fields() (kind, field2, field3 int)
+}
+
+// newXRefStream returns a cross-reference stream for entries, numbered from 0, which also carries the trailer entries.
+// Its fields are 1, 4 and 2 bytes wide.
+func newXRefStream(seq, gen int, trailer dictionary, entries []xRefEntry) *stream {
+	data := make([]byte, 0, len(entries)*7)
+	for _, e := range entries {
+		kind, f2, f3 := e.fields()
+		data = append(data, byte(kind), byte(f2>>24), byte(f2>>16), byte(f2>>8), byte(f2), byte(f3>>8), byte(f3))
+	}
+	s := newStream(seq, gen, data)
+	for k, v := range trailer {
+		s.dict[k] = v
+	}
+	s.dict["Type"] = name("XRef")
+	s.dict["Size"] = integer(len(entries))
+	s.dict["W"] = arrayFromInts([]int{1, 4, 2})
+	return s
+}
+
 type xRefSubSection struct {
 	list array
 }
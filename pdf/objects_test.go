@@ -5,6 +5,10 @@ package pdf
 
 import (
 	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"testing"
 )
 
@@ -53,6 +57,42 @@ func TestCatalog(t *testing.T) {
 	expectS(t, "3 0 obj\n<<\n/Outlines 2 0 R \n/PageMode /UseNone \n/Pages 1 0 R \n/Type /Catalog \n>>\nendobj\n", buf.String())
 }
 
+func TestCIDFontType2(t *testing.T) {
+	fd := newFontDescriptor(100, 0,
+		"ABCDEF+ArialMT", "Arial",
+		32,
+		[4]int{-665, -325, 2029, 1006},
+		0, 0, 0,
+		9.1,
+		723, 525, 905, -212, 33, 2000, 0)
+	f := newCIDFontType2(200, 0, "ABCDEF+ArialMT", fd)
+	f.setWidths(array{integer(36), array{integer(667)}})
+
+	expected := "200 0 obj\n<<\n" +
+		"/BaseFont /ABCDEF+ArialMT \n" +
+		"/CIDSystemInfo <<\n/Ordering (Identity) \n/Registry (Adobe) \n/Supplement 0 \n>>\n\n" +
+		"/CIDToGIDMap /Identity \n" +
+		"/FontDescriptor 100 0 R \n" +
+		"/Subtype /CIDFontType2 \n" +
+		"/Type /Font \n" +
+		"/W [36 [667 ] ] \n" +
+		">>\nendobj\n"
+	expectS(t, expected, stringFromWriter(f))
+}
+
+func TestCountingWriter(t *testing.T) {
+	var buf bytes.Buffer
+	cw := &countingWriter{w: &buf}
+	fmt.Fprintf(cw, "%d obj", 12)
+	expectI(t, 6, cw.Len())
+	cw.err = io.ErrClosedPipe
+	n, err := cw.Write([]byte("endobj"))
+	expectI(t, 0, n)
+	check(t, err == io.ErrClosedPipe, "Write should return latched error.")
+	expectS(t, "12 obj", buf.String())
+	expectI(t, 6, cw.Len())
+}
+
 func TestDictionary(t *testing.T) {
 	var buf bytes.Buffer
 	d := dictionary{"foo": str("bar"), "baz": integer(7)}
@@ -79,6 +119,46 @@ func TestFile(t *testing.T) {
 	expectS(t, "%PDF-1.3\nxref\n0 1\n0000000000 65535 f\ntrailer\n<<\n/Size 1 \n>>\nstartxref\n9\n%%EOF\n", stringFromWriter(f))
 }
 
+func TestFile_flush(t *testing.T) {
+	var buf bytes.Buffer
+	f := newFile()
+	root := newDictionaryObject(1, 0)
+	obj := &indirectObject{2, 0, integer(42)}
+	f.body.add(root, obj)
+	f.trailer.setRoot(root)
+	f.begin(&buf)
+	f.flush(obj)
+	expectS(t, "%PDF-1.3\n2 0 obj\n42 \nendobj\n", buf.String())
+	expectI(t, 1, len(f.body.list))
+	f.finish()
+	expected := "%PDF-1.3\n2 0 obj\n42 \nendobj\n" +
+		"1 0 obj\n<<\n>>\nendobj\n" +
+		"xref\n0 3\n0000000000 65535 f\n0000000028 00000 n\n0000000009 00000 n\n" +
+		"trailer\n<<\n/Root 1 0 R \n/Size 3 \n>>\nstartxref\n49\n%%EOF\n"
+	expectS(t, expected, buf.String())
+}
+
+func TestFile_writeCompact(t *testing.T) {
+	f := newFile()
+	f.objectStreams = true
+	f.compression = NoCompression
+	catalog := newDictionaryObject(1, 0)
+	f.body.add(catalog, newStream(2, 0, []byte("BT ET")))
+	f.trailer.setRoot(catalog)
+	expected := "%PDF-1.3\n" +
+		"2 0 obj\n<<\n/Length 5 \n>>\nstream\nBT ET\nendstream\nendobj\n" +
+		"3 0 obj\n<<\n/First 4 \n/Length 10 \n/N 1 \n/Type /ObjStm \n>>\nstream\n1 0 <<\n>>\n\nendstream\nendobj\n" +
+		"4 0 obj\n<<\n/Length 35 \n/Root 1 0 R \n/Size 5 \n/Type /XRef \n/W [1 4 2 ] \n>>\nstream\n" +
+		"\x00\x00\x00\x00\x00\xFF\xFF" +
+		"\x02\x00\x00\x00\x03\x00\x00" +
+		"\x01\x00\x00\x00\x09\x00\x00" +
+		"\x01\x00\x00\x00\x40\x00\x00" +
+		"\x01\x00\x00\x00\x9C\x00\x00" +
+		"\nendstream\nendobj\n" +
+		"startxref\n156\n%%EOF\n"
+	expectS(t, expected, stringFromWriter(f))
+}
+
 func TestFontDescriptor(t *testing.T) {
 	fd := newFontDescriptor(100, 0,
 		"ArialMT", "Arial",
@@ -112,6 +192,18 @@ func TestFontDescriptor(t *testing.T) {
 	expectS(t, expected, buf.String())
 }
 
+func TestFontDescriptor_setFontFile2(t *testing.T) {
+	fd := newFontDescriptor(100, 0,
+		"ABCDEF+ArialMT", "Arial",
+		32,
+		[4]int{-665, -325, 2029, 1006},
+		0, 0, 0,
+		9.1,
+		723, 525, 905, -212, 33, 2000, 0)
+	fd.setFontFile2(newStream(99, 0, nil))
+	expectS(t, "99 0 R ", stringFromWriter(fd.dict["FontFile2"]))
+}
+
 func TestFontEncoding(t *testing.T) {
 	differences := array{integer(32), name("space")}
 	fe := newFontEncoding(1, 0, "MacRomanEncoding", differences)
@@ -135,6 +227,14 @@ func TestFreeXRefEntry(t *testing.T) {
 	expectS(t, "0000000001 00000 f\n", buf.String())
 }
 
+func TestHeader_requireVersion(t *testing.T) {
+	h := &header{}
+	h.requireVersion(1.5)
+	expectS(t, "%PDF-1.5\n", stringFromWriter(h))
+	h.requireVersion(1.4)
+	expectS(t, "%PDF-1.5\n", stringFromWriter(h))
+}
+
 func TestHeader(t *testing.T) {
 	var buf bytes.Buffer
 	h := &header{}
@@ -143,6 +243,11 @@ func TestHeader(t *testing.T) {
 	expectS(t, "%PDF-1.3\n", buf.String())
 }
 
+func TestHexStr(t *testing.T) {
+	expectS(t, "<00FF7A> ", stringFromWriter(hexStr{0x00, 0xFF, 0x7A}))
+	expectS(t, "<> ", stringFromWriter(hexStr{}))
+}
+
 func TestIndirectObject(t *testing.T) {
 	var buf bytes.Buffer
 	obj := &indirectObject{1, 0, nil}
@@ -185,6 +290,10 @@ func TestName(t *testing.T) {
 	name("name").write(&buf)
 
 	expectS(t, "/name ", buf.String())
+
+	buf.Reset()
+	name("PANTONE 185 C#(1)").write(&buf)
+	expectS(t, "/PANTONE#20185#20C#23#281#29 ", buf.String())
 }
 
 func nameShouldEqual(t *testing.T, expected string, actual writer) {
@@ -201,6 +310,18 @@ func TestNameArray(t *testing.T) {
 	nameShouldEqual(t, "ImageC", a[3])
 }
 
+func TestNewObjectStream(t *testing.T) {
+	objs := []genWriter{
+		&indirectObject{5, 0, integer(42)},
+		&dictionaryObject{indirectObject{6, 0, nil}, dictionary{"foo": str("bar")}},
+	}
+	s := newObjectStream(7, 0, objs)
+	expectS(t, "5 0 6 4 42 \n<<\n/foo (bar) \n>>\n", string(s.data))
+	expectS(t, "8 ", stringFromWriter(s.dict["First"]))
+	expectS(t, "2 ", stringFromWriter(s.dict["N"]))
+	expectS(t, "/ObjStm ", stringFromWriter(s.dict["Type"]))
+}
+
 func TestNumber(t *testing.T) {
 	var buf bytes.Buffer
 	ni := int(7)
@@ -226,6 +347,33 @@ func TestOutlines(t *testing.T) {
 	var buf bytes.Buffer
 	o.write(&buf)
 	expectS(t, "1 0 obj\n<<\n/Count 0 \n/Type /Outlines \n>>\nendobj\n", buf.String())
+
+	p := newPage(2, 0, nil)
+	item1 := newOutlineItem(3, 0, str("One"), o, array{&indirectObjectRef{p}, name("Fit")})
+	item2 := newOutlineItem(4, 0, str("Two"), o, array{&indirectObjectRef{p}, name("Fit")})
+	item3 := newOutlineItem(5, 0, str("Three"), item2, array{&indirectObjectRef{p}, name("Fit")})
+	o.add(item1)
+	o.add(item2)
+	item2.add(item3)
+	expectS(t, "1 0 obj\n<<\n/Count 2 \n/First 3 0 R \n/Last 4 0 R \n/Type /Outlines \n>>\nendobj\n", stringFromWriter(o))
+	expectS(t, "3 0 obj\n<<\n/Dest [2 0 R /Fit ] \n/Next 4 0 R \n/Parent 1 0 R \n/Title (One) \n>>\nendobj\n", stringFromWriter(item1))
+	expectS(t, "4 0 obj\n<<\n/Count -1 \n/Dest [2 0 R /Fit ] \n/First 5 0 R \n/Last 5 0 R \n/Parent 1 0 R \n/Prev 3 0 R \n/Title (Two) \n>>\nendobj\n", stringFromWriter(item2))
+
+	item2.open = true
+	expectS(t, "1 0 obj\n<<\n/Count 3 \n/First 3 0 R \n/Last 4 0 R \n/Type /Outlines \n>>\nendobj\n", stringFromWriter(o))
+	expectS(t, "4 0 obj\n<<\n/Count 1 \n/Dest [2 0 R /Fit ] \n/First 5 0 R \n/Last 5 0 R \n/Parent 1 0 R \n/Prev 3 0 R \n/Title (Two) \n>>\nendobj\n", stringFromWriter(item2))
+}
+
+func TestOutlineItem_style(t *testing.T) {
+	item := newOutlineItem(1, 0, str("One"), &indirectObject{2, 0, nil}, array{})
+	item.setColor(1, 0.5, 0)
+	item.setStyle(true, true)
+	expectS(t, "[1 0.5 0 ] ", stringFromWriter(item.dict["C"]))
+	expectS(t, "3 ", stringFromWriter(item.dict["F"]))
+	item.setStyle(false, true)
+	expectS(t, "1 ", stringFromWriter(item.dict["F"]))
+	item.setStyle(false, false)
+	check(t, item.dict["F"] == nil, "F should be removed.")
 }
 
 func TestPage(t *testing.T) {
@@ -403,12 +551,28 @@ func TestSimpleFont_TrueType(t *testing.T) {
 		"ArialMT", // baseFont
 		0, 255,    // firstChar, lastChar
 		&indirectObject{50, 0, &widths}, // widths
-		fd) // fontDescriptor
+		fd, nil) // fontDescriptor, fontEncoding
 
 	expected := "200 0 obj\n<<\n/BaseFont /ArialMT \n/FirstChar 0 \n/FontDescriptor 100 0 R \n/LastChar 255 \n/Subtype /TrueType \n/Type /Font \n/Widths 50 0 R \n>>\nendobj\n"
 	expectS(t, expected, stringFromWriter(f))
 }
 
+func TestSimpleFont_setToUnicode(t *testing.T) {
+	widths := arrayFromInts(arial1252Widths)
+	fd := newFontDescriptor(100, 0,
+		"ArialMT", "Arial",
+		32,
+		[4]int{-665, -325, 2029, 1006},
+		0, 0, 0,
+		9.1,
+		723, 525, 905, -212, 33, 2000, 0)
+	f := newTrueTypeFont(200, 0, "ArialMT", 32, 255, &indirectObject{50, 0, &widths}, fd, nil)
+	f.setToUnicode(newStream(60, 0, nil))
+
+	expected := "200 0 obj\n<<\n/BaseFont /ArialMT \n/FirstChar 32 \n/FontDescriptor 100 0 R \n/LastChar 255 \n/Subtype /TrueType \n/ToUnicode 60 0 R \n/Type /Font \n/Widths 50 0 R \n>>\nendobj\n"
+	expectS(t, expected, stringFromWriter(f))
+}
+
 func TestSimpleFont_Type1(t *testing.T) {
 	widths := arrayFromInts(arial1252Widths)
 	fd := newFontDescriptor(100, 0, // seq, gen
@@ -434,6 +598,7 @@ func TestStr(t *testing.T) {
 	expectS(t, "a\\\\b\\(cd\\)", string(s.escape()))
 	s.write(&buf)
 	expectS(t, "(a\\\\b\\(cd\\)) ", buf.String())
+	expectS(t, "\\r\n", string(str("\r\n").escape()))
 }
 
 func TestStream(t *testing.T) {
@@ -448,10 +613,61 @@ func TestStream(t *testing.T) {
 	}
 	var buf bytes.Buffer
 	s.writeBody(&buf)
-	expectS(t, "<<\n/Filter /bogus \n/Length 4 \n>>\nstream\ntestendstream\n", buf.String())
+	expectS(t, "<<\n/Filter /bogus \n/Length 4 \n>>\nstream\ntest\nendstream\n", buf.String())
 	buf.Reset()
 	s.write(&buf)
-	expectS(t, "1 0 obj\n<<\n/Filter /bogus \n/Length 4 \n>>\nstream\ntestendstream\nendobj\n", buf.String())
+	expectS(t, "1 0 obj\n<<\n/Filter /bogus \n/Length 4 \n>>\nstream\ntest\nendstream\nendobj\n", buf.String())
+}
+
+func TestStream_compress(t *testing.T) {
+	data := bytes.Repeat([]byte("0 0 m 72 72 l S\n"), 10)
+	s := newStream(1, 0, data)
+	check(t, s.compress(NoCompression) == nil, "compress should succeed")
+	check(t, s.dict["Filter"] == nil, "Filter should not be set without compression.")
+	check(t, bytes.Equal(s.data, data), "Data should be unchanged without compression.")
+
+	check(t, s.compress(DefaultCompression) == nil, "compress should succeed")
+	check(t, s.dict["Filter"] == name("FlateDecode"), "Filter should be FlateDecode.")
+	check(t, len(s.data) < len(data), "Data should be smaller.")
+	zr, err := zlib.NewReader(bytes.NewReader(s.data))
+	checkFatal(t, err == nil, "Data should be zlib encoded.")
+	decoded, _ := ioutil.ReadAll(zr)
+	check(t, bytes.Equal(decoded, data), "Data should decode to original.")
+
+	compressed := s.data
+	check(t, s.compress(BestCompression) == nil, "compress should succeed")
+	check(t, bytes.Equal(s.data, compressed), "Filtered stream should not be compressed again.")
+}
+
+func TestType0Font(t *testing.T) {
+	cf := newCIDFontType2(200, 0, "ABCDEF+ArialMT", newFontDescriptor(100, 0,
+		"ABCDEF+ArialMT", "Arial",
+		32,
+		[4]int{-665, -325, 2029, 1006},
+		0, 0, 0,
+		9.1,
+		723, 525, 905, -212, 33, 2000, 0))
+	f := newType0Font(201, 0, "ABCDEF+ArialMT", "Identity-H", cf)
+
+	expected := "201 0 obj\n<<\n" +
+		"/BaseFont /ABCDEF+ArialMT \n" +
+		"/DescendantFonts [200 0 R ] \n" +
+		"/Encoding /Identity-H \n" +
+		"/Subtype /Type0 \n" +
+		"/Type /Font \n" +
+		">>\nendobj\n"
+	expectS(t, expected, stringFromWriter(f))
+
+	f.setToUnicode(newStream(202, 0, nil))
+	expected = "201 0 obj\n<<\n" +
+		"/BaseFont /ABCDEF+ArialMT \n" +
+		"/DescendantFonts [200 0 R ] \n" +
+		"/Encoding /Identity-H \n" +
+		"/Subtype /Type0 \n" +
+		"/ToUnicode 202 0 R \n" +
+		"/Type /Font \n" +
+		">>\nendobj\n"
+	expectS(t, expected, stringFromWriter(f))
 }
 
 func TestTrailer(t *testing.T) {
@@ -475,6 +691,12 @@ func TestTrailer(t *testing.T) {
 	}
 	tr.write(&buf)
 	expectS(t, "trailer\n<<\n/Size 3 \n>>\nstartxref\n0\n%%EOF\n", buf.String())
+
+	buf.Reset()
+	tr.setInfo(&indirectObject{2, 0, nil})
+	tr.setID([]byte{0xAB, 0xCD})
+	tr.write(&buf)
+	expectS(t, "trailer\n<<\n/ID [<ABCD> <ABCD> ] \n/Info 2 0 R \n/Size 3 \n>>\nstartxref\n0\n%%EOF\n", buf.String())
 }
 
 func TestXRefSubSection(t *testing.T) {
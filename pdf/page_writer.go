@@ -7,6 +7,8 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"math"
 	"strings"
 
@@ -29,6 +31,8 @@ const (
 type PageWriter struct {
 	drawState
 	autoPath   bool
+	cell       *Pattern
+	ctm        Matrix
 	dw         *DocWriter
 	fonts      []*font.Font
 	gw         *graphWriter
@@ -46,6 +50,7 @@ type PageWriter struct {
 	page       *page
 	pageHeight float64
 	pageWidth  float64
+	states     []savedState
 	stream     bytes.Buffer
 	tw         *textWriter
 	units      *units
@@ -59,16 +64,15 @@ func newPageWriter(dw *DocWriter, options options.Options) *PageWriter {
 func clonePageWriter(opw *PageWriter) *PageWriter {
 	pw := new(PageWriter).init(opw.dw, opw.options)
 	pw.drawState = opw.drawState
+	// The new page starts untransformed, so carry the location over in page coordinates.
+	pw.loc.X, pw.loc.Y = opw.ctm.apply(opw.loc.X, opw.loc.Y)
 	pw.units = opw.units
 	pw.fonts = append(pw.fonts, opw.fonts...)
 	return pw
 }
 
 func (pw *PageWriter) init(dw *DocWriter, options options.Options) *PageWriter {
-	pw.dw = dw
-	pw.options = options
-	pw.lineSpacing = options.FloatDefault("line_spacing", 1.0)
-	pw.units = UnitConversions[options.StringDefault("units", "pt")]
+	pw.initWriter(dw, options)
 	ps := newPageStyle(options)
 	pw.pageHeight = ps.pageSize.y2
 	pw.pageWidth = ps.pageSize.x2
@@ -78,18 +82,40 @@ func (pw *PageWriter) init(dw *DocWriter, options options.Options) *PageWriter {
 	pw.page.setRotate(ps.rotate)
 	pw.page.setResources(pw.dw.resources)
 	pw.dw.file.body.add(pw.page)
+
+	return pw
+}
+
+// initWriter prepares pw to write content, whether for a page or a template.
+func (pw *PageWriter) initWriter(dw *DocWriter, options options.Options) *PageWriter {
+	pw.dw = dw
+	pw.options = options
+	pw.lineSpacing = options.FloatDefault("line_spacing", 1.0)
+	pw.units = UnitConversions[options.StringDefault("units", "pt")]
 	pw.autoPath = true
 	pw.mw = newMiscWriter(&pw.stream)
 	pw.tw = newTextWriter(&pw.stream)
 	pw.gw = newGraphWriter(&pw.stream)
-
+	pw.ctm = identityMatrix
+	pw.fillColor, pw.fontColor, pw.lineColor = colors.Black, colors.Black, colors.Black
+	pw.last.fillColor, pw.last.lineColor = colors.Black, colors.Black
+	pw.initTransparency()
 	return pw
 }
 
+// AddFont appends a font to the list used when printing text.
+// Options are passed to font.New, with the addition of:
+//   composite: Show text with a composite (Type0) font, so that any rune with a glyph in a TrueType font can be printed.
+// Fonts that cannot be embedded are rejected in PDF/A documents.
 func (pw *PageWriter) AddFont(family string, options options.Options) ([]*font.Font, error) {
 	if font, err := font.New(family, options, pw.dw.fontSources); err != nil {
 		return nil, err
+	} else if err := pw.dw.checkEmbeddable(font); err != nil {
+		return nil, err
 	} else {
+		if options.BoolDefault("composite", false) {
+			pw.dw.composite[font.Filename()] = true
+		}
 		return pw.addFont(font), nil
 	}
 }
@@ -120,15 +146,28 @@ func (pw *PageWriter) carriageReturn() {
 }
 
 func (pw *PageWriter) checkSetFillColor() {
-	if pw.fillColor == pw.last.fillColor {
+	pw.checkSetExtGState()
+	if pw.colorless() {
+		return
+	}
+	if pw.fillGradient != nil {
+		pw.checkSetFillGradient()
+		return
+	}
+	if pw.fillPattern != nil {
+		pw.checkSetFillPattern()
+		return
+	}
+	if pw.fillColor == pw.last.fillColor && pw.last.fillPatternName == "" {
 		return
 	}
 	if pw.inPath && pw.autoPath {
 		pw.gw.stroke()
 		pw.inPath = false
 	}
-	pw.mw.setRgbColorFill(pw.fillColor.RGB64())
+	pw.setPaintFill(pw.fillColor)
 	pw.last.fillColor = pw.fillColor
+	pw.last.fillPatternName = ""
 }
 
 func (pw *PageWriter) checkSetFont() {
@@ -143,26 +182,29 @@ func (pw *PageWriter) checkSetFont() {
 }
 
 func (pw *PageWriter) checkSetFontColor() {
-	if pw.fontColor == pw.last.fillColor {
+	pw.checkSetExtGState()
+	if pw.colorless() || (pw.fontColor == pw.last.fillColor && pw.last.fillPatternName == "") {
 		return
 	}
 	if pw.inPath && pw.autoPath {
 		pw.gw.stroke()
 		pw.inPath = false
 	}
-	pw.mw.setRgbColorFill(pw.fontColor.RGB64())
+	pw.setPaintFill(pw.fontColor)
 	pw.last.fillColor = pw.fontColor
+	pw.last.fillPatternName = ""
 }
 
 func (pw *PageWriter) checkSetLineColor() {
-	if pw.lineColor == pw.last.lineColor {
+	pw.checkSetExtGState()
+	if pw.colorless() || pw.lineColor == pw.last.lineColor {
 		return
 	}
 	if pw.inPath && pw.autoPath {
 		pw.gw.stroke()
 		pw.inPath = false
 	}
-	pw.mw.setRgbColorStroke(pw.lineColor.RGB64())
+	pw.setPaintStroke(pw.lineColor)
 	pw.last.lineColor = pw.lineColor
 }
 
@@ -218,10 +260,10 @@ func (pw *PageWriter) close() {
 	// end sub page
 	pw.endText()
 	pw.endGraph()
-	// compress stream
+	pw.restoreStates()
 	pdfStream := newStream(pw.dw.nextSeq(), 0, pw.stream.Bytes())
+	pw.dw.compress(pdfStream)
 	pw.dw.file.body.add(pdfStream)
-	// set annots
 	pw.page.add(pdfStream)
 	pw.dw.catalog.pages.add(pw.page) // unless reusing page
 	pw.stream.Reset()
@@ -247,7 +289,7 @@ func (pw *PageWriter) CurvePoints(points []Location) error {
 	pw.checkSetLineDashPattern()
 
 	if !(pw.loc.equal(pw.last.loc) && pw.inPath) {
-		pw.gw.moveTo(pw.units.toPts(pw.loc.X), pw.units.toPts(pw.loc.Y))
+		pw.gw.moveTo(pw.loc.X, pw.loc.Y)
 	}
 	i := 1
 	for i+2 < len(points) {
@@ -265,12 +307,32 @@ func (pw *PageWriter) CurvePoints(points []Location) error {
 
 func (pw *PageWriter) drawUnderline(loc1 Location, loc2 Location, position float64, thickness float64) {
 	saveWidth := pw.setLineWidth(thickness)
-	// TODO: rotate coordiates given angle
-	pw.moveTo(loc1.X, loc1.Y+position)
-	pw.lineTo(loc2.X, loc2.Y+position)
+	// Offset the line from the baseline perpendicularly, which is straight up or down unless printing at an angle.
+	cos, sin := pw.textDirection()
+	pw.moveTo(loc1.X-sin*position, loc1.Y+cos*position)
+	pw.lineTo(loc2.X-sin*position, loc2.Y+cos*position)
 	pw.setLineWidth(saveWidth)
 }
 
+// ellipsePath draws an ellipse centred at x, y with radii rx and ry, counterclockwise on the page unless reverse is true.
+func (pw *PageWriter) ellipsePath(x, y, rx, ry float64, reverse bool) {
+	qpa := [][]Location{
+		quadrantBezierPoints(1, x, y, rx, ry),
+		quadrantBezierPoints(2, x, y, rx, ry),
+		quadrantBezierPoints(3, x, y, rx, ry),
+		quadrantBezierPoints(4, x, y, rx, ry),
+	}
+	if reverse {
+		LocationSliceSlice(qpa).Reverse()
+		for _, qp := range qpa {
+			LocationSlice(qp).Reverse()
+		}
+	}
+	for _, qp := range qpa {
+		pw.CurvePoints(qp)
+	}
+}
+
 func (pw *PageWriter) endGraph() {
 	if pw.inPath {
 		pw.endPath()
@@ -287,6 +349,9 @@ func (pw *PageWriter) endPath() {
 
 func (pw *PageWriter) endText() {
 	pw.flushText()
+	if !pw.inText {
+		return
+	}
 	pw.tw.close()
 	pw.inText = false
 }
@@ -295,22 +360,37 @@ func (pw *PageWriter) flushText() {
 	if pw.line == nil || pw.flushing {
 		return
 	}
+	var err error
+	pw.line.VisitAll(func(p *rich_text.RichText) {
+		// Empty pieces, such as the root a paragraph is added to, are never shown and need no font.
+		if p.IsLeaf() && p.Text != "" && err == nil {
+			err = checkFont(p.Font)
+		}
+	})
+	if err != nil {
+		pw.dw.setErr(err)
+		pw.line = nil
+		return
+	}
 	pw.flushing = true
 	pw.startText()
-	if pw.loc != pw.last.loc {
+	cos, sin := pw.textDirection()
+	if pw.textAngle != 0 || pw.last.textAngle != 0 {
+		// Text moves are made in the rotated space of the text matrix, so set the matrix afresh for each line.
+		pw.tw.setMatrix(cos, sin, 0-sin, cos, pw.loc.X, pw.loc.Y)
+		pw.last.textAngle = pw.textAngle
+	} else if pw.loc != pw.last.loc {
 		pw.tw.moveBy(pw.loc.X-pw.last.loc.X, pw.loc.Y-pw.last.loc.Y)
 	}
 	loc1 := pw.loc
 	var buf bytes.Buffer
 	pw.line.Merge().EachCodepage(func(cpi codepage.CodepageIndex, text string, p *rich_text.RichText) {
-		if p.Font == nil {
-			fmt.Println(cpi)
-			fmt.Println(text)
-			panic("EachCodepage calling back with nil p")
-		}
 		buf.Reset()
 		// fmt.Println(cpi)
-		if cpi < 0 {
+		composite := pw.dw.isComposite(p.Font) || (cpi < 0 && pw.dw.fallback && p.Font.SubType() == "TrueType" && p.Font.Embeddable())
+		if composite {
+			buf.Write(pw.dw.fontFile(p.Font).glyphIndexes(text))
+		} else if cpi < 0 {
 			// buf.WriteString(text)
 		} else {
 			cp := cpi.Codepage()
@@ -321,7 +401,21 @@ func (pw *PageWriter) flushText() {
 		}
 		pw.SetFontColor(p.Color)
 		pw.checkSetFontColor()
-		pw.fontKey = pw.dw.fontKey(p.Font, cpi)
+		var key string
+		var err error
+		if composite {
+			key, err = pw.dw.compositeFontKey(p.Font)
+		} else {
+			key, err = pw.dw.fontKey(p.Font, cpi)
+		}
+		if err != nil {
+			pw.dw.setErr(err)
+			return
+		}
+		pw.fontKey = key
+		if buf.Len() > 0 {
+			pw.dw.addFontRunes(p.Font, text)
+		}
 		pw.SetFontSize(p.FontSize)
 		pw.checkSetFont()
 		pw.charSpacing = p.CharSpacing
@@ -329,29 +423,48 @@ func (pw *PageWriter) flushText() {
 		pw.checkSetSpacing()
 		pw.tw.show(buf.Bytes())
 	})
+	// Drawing underlines moves the pen, so remember where the line started.
+	start, lineHeight := pw.loc, pw.lineHeight
+	var link *rectangle
+	var linkTarget string
 	pw.line.VisitAll(func(p *rich_text.RichText) {
 		if !p.IsLeaf() {
 			return
 		}
-		loc2 := Location{loc1.X + p.Width(), loc1.Y} // TODO: Adjust if print at an angle.
+		loc2 := Location{loc1.X + cos*p.Width(), loc1.Y + sin*p.Width()}
 		if p.Underline {
 			pw.drawUnderline(loc1, loc2, p.UnderlinePosition, p.UnderlineThickness)
 		}
 		if p.Strikeout {
 			pw.drawUnderline(loc1, loc2, p.StrikeoutPosition, p.StrikeoutThickness)
 		}
+		// Extend the rectangle of the previous piece if it has the same link.
+		if p.Link == "" {
+			link = nil
+		} else if rect := pw.textRect(loc1, loc2, p.Descent(), p.Ascent()); link != nil && p.Link == linkTarget {
+			link.x1 = math.Min(link.x1, rect.x1)
+			link.y1 = math.Min(link.y1, rect.y1)
+			link.x2 = math.Max(link.x2, rect.x2)
+			link.y2 = math.Max(link.y2, rect.y2)
+		} else {
+			link = rect
+			linkTarget = p.Link
+			pw.addTextLink(link, p.Link)
+		}
 		loc1 = loc2
 	})
-	pw.last.loc = pw.loc
-	pw.lineHeight = math.Max(pw.lineHeight, pw.line.Leading()*pw.lineSpacing)
-	pw.loc.X += pw.line.Width()
-	// TODO: Adjust pw.loc.y if printing at an angle.
+	if pw.inText {
+		pw.last.loc = start
+	}
+	pw.lineHeight = math.Max(lineHeight, pw.line.Leading()*pw.lineSpacing)
+	pw.loc.X = start.X + cos*pw.line.Width()
+	pw.loc.Y = start.Y + sin*pw.line.Width()
 
 	pw.line = nil
 	pw.flushing = false
 }
 
-func (pw *PageWriter) FontColor() colors.Color {
+func (pw *PageWriter) FontColor() colors.Paint {
 	return pw.fontColor
 }
 
@@ -374,7 +487,7 @@ func (pw *PageWriter) LineCapStyle() LineCapStyle {
 	return pw.lineCapStyle
 }
 
-func (pw *PageWriter) LineColor() colors.Color {
+func (pw *PageWriter) LineColor() colors.Paint {
 	return pw.lineColor
 }
 
@@ -438,7 +551,8 @@ func (pw *PageWriter) newLine() {
 			pw.lineHeight = rt.Leading() * pw.lineSpacing
 		}
 	}
-	pw.moveTo(pw.origin.X, pw.origin.Y-pw.lineHeight)
+	cos, sin := pw.textDirection()
+	pw.moveTo(pw.origin.X+sin*pw.lineHeight, pw.origin.Y-cos*pw.lineHeight)
 }
 
 func (pw *PageWriter) PageHeight() float64 {
@@ -479,19 +593,60 @@ func (pw *PageWriter) print(text string) (err error) {
 	return
 }
 
+// PrintImage places a JPEG, PNG or GIF image with its top left corner at x, y.
+// If width or height is zero, it is calculated from the other to preserve the image's aspect ratio.
+// If both are zero, the image is printed at one point per pixel.
+// Images are embedded once per document, however many times they are printed.
+func (pw *PageWriter) PrintImage(r io.Reader, x, y, width, height float64) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	img, err := pw.dw.image(data)
+	if err != nil {
+		return err
+	}
+	width, height = img.size(width, height, pw.units)
+	xpts, ypts := pw.units.toPts(x), pw.translate(pw.units.toPts(y+height))
+	wpts, hpts := pw.units.toPts(width), pw.units.toPts(height)
+
+	pw.startGraph()
+	if pw.inPath && pw.autoPath {
+		pw.gw.stroke()
+		pw.inPath = false
+	}
+	pw.checkSetExtGState()
+	pw.gw.saveGraphicsState()
+	pw.gw.concatMatrix(wpts, 0, 0, hpts, xpts, ypts)
+	pw.mw.xObject(img.name)
+	pw.gw.restoreGraphicsState()
+	pw.MoveTo(x+width, y)
+	return nil
+}
+
+// PrintParagraph prints each piece of para on a line of its own.
+// Options include:
+//   angle: Degrees counterclockwise at which to print, overriding the text angle.
+//   text-align: left, center, right or justify.
+//   width: Width within which to align the lines.
 func (pw *PageWriter) PrintParagraph(para []*rich_text.RichText, options options.Options) {
 	pw.flushText()
+	prevAngle := pw.SetTextAngle(options.FloatDefault("angle", pw.textAngle))
+	defer pw.SetTextAngle(prevAngle)
+	cos, sin := pw.textDirection()
 	width := options.FloatDefault("width", pw.PageWidth()-pw.loc.X)
 	for _, p := range para {
 		pw.origin = pw.loc
 		switch options.StringDefault("text-align", "left") {
 		case "center":
 			pw.keepOrigin = true
-			pw.loc = Location{pw.loc.X + (width-p.Width())/2, pw.loc.Y}
+			offset := (width - p.Width()) / 2
+			pw.loc = Location{pw.loc.X + cos*offset, pw.loc.Y + sin*offset}
 			fmt.Println("center", pw.loc)
 		case "right":
 			pw.keepOrigin = true
-			pw.loc = Location{pw.loc.X + width - p.Width(), pw.loc.Y}
+			offset := width - p.Width()
+			pw.loc = Location{pw.loc.X + cos*offset, pw.loc.Y + sin*offset}
 		case "justify":
 			delta := width - p.Width()
 			spaces := 0
@@ -539,6 +694,7 @@ func (pw *PageWriter) PrintRichText(text *rich_text.RichText) {
 	}
 }
 
+// PrintWithOptions prints text, wrapped to fit if a width is given, with options as for PrintParagraph.
 func (pw *PageWriter) PrintWithOptions(text string, options options.Options) (err error) {
 	var para []*rich_text.RichText
 	rt, err := pw.richTextForString(text)
@@ -672,37 +828,52 @@ func (pw *PageWriter) SetFont(name string, size float64, options options.Options
 	return pw.AddFont(name, options)
 }
 
-func (pw *PageWriter) SetFillColor(value interface{}) (prev colors.Color) {
+// SetFillColor sets the colour with which to fill shapes: a colors.Paint such as a colors.Color or colors.CMYK,
+// a string parsed by colors.ParsePaint, an integer RGB colour, or a *Gradient or *Pattern to fill with instead
+// until another colour is set. An uncoloured pattern is painted in the colour set before it.
+func (pw *PageWriter) SetFillColor(value interface{}) (prev colors.Paint) {
 	prev = pw.fillColor
 
 	switch value := value.(type) {
 	case string:
-		if c, err := colors.NamedColor(value); err == nil {
-			pw.fillColor = c
+		if p, err := colors.ParsePaint(value); err == nil {
+			pw.fillColor = p
+			pw.fillGradient, pw.fillPattern = nil, nil
 		}
 	case int:
 		pw.fillColor = colors.Color(value)
+		pw.fillGradient, pw.fillPattern = nil, nil
 	case int32:
 		pw.fillColor = colors.Color(value)
-	case colors.Color:
+		pw.fillGradient, pw.fillPattern = nil, nil
+	case colors.Paint:
 		pw.fillColor = value
+		pw.fillGradient, pw.fillPattern = nil, nil
+	case *Gradient:
+		pw.fillGradient, pw.fillPattern = value, nil
+	case *Pattern:
+		pw.fillGradient, pw.fillPattern = nil, value
 	}
 
 	return
 }
 
-func (pw *PageWriter) SetFontColor(value interface{}) (prev colors.Color) {
+// SetFontColor sets the colour of text, given as for SetFillColor but without gradients or patterns.
+func (pw *PageWriter) SetFontColor(value interface{}) (prev colors.Paint) {
 	prev = pw.fontColor
 
 	switch value := value.(type) {
 	case string:
-		c, _ := colors.NamedColor(value)
-		pw.fontColor = c
+		if p, err := colors.ParsePaint(value); err == nil {
+			pw.fontColor = p
+		} else {
+			pw.fontColor = colors.Black
+		}
 	case int:
 		pw.fontColor = colors.Color(value)
 	case int32:
 		pw.fontColor = colors.Color(value)
-	case colors.Color:
+	case colors.Paint:
 		pw.fontColor = value
 	}
 
@@ -733,6 +904,9 @@ func (pw *PageWriter) SetFontStyle(style string) (prev string, err error) {
 		} else {
 			options["ranges"] = font.Ranges
 		}
+		if pw.dw.isComposite(font) {
+			options["composite"] = true
+		}
 		if _, err = pw.AddFont(font.Family(), options); err != nil {
 			break
 		}
@@ -746,7 +920,7 @@ func (pw *PageWriter) SetLineCapStyle(lineCapStyle LineCapStyle) (prev LineCapSt
 	return
 }
 
-func (pw *PageWriter) SetLineColor(value colors.Color) (prev colors.Color) {
+func (pw *PageWriter) SetLineColor(value colors.Paint) (prev colors.Paint) {
 	prev = pw.lineColor
 	pw.lineColor = value
 	return
@@ -781,6 +955,17 @@ func (pw *PageWriter) setLineWidth(width float64) (prev float64) {
 	return
 }
 
+// SetTextAngle sets the angle, in degrees counterclockwise, at which text is printed.
+// Text already printed keeps the angle it was printed at.
+func (pw *PageWriter) SetTextAngle(angle float64) (prev float64) {
+	prev = pw.textAngle
+	if angle != prev {
+		pw.flushText()
+	}
+	pw.textAngle = angle
+	return
+}
+
 func (pw *PageWriter) SetUnderline(underline bool) (prev bool) {
 	prev = pw.underline
 	pw.underline = underline
@@ -810,6 +995,7 @@ func (pw *PageWriter) startText() {
 		pw.endGraph()
 	}
 	pw.last.loc = Location{0, 0}
+	pw.last.textAngle = 0
 	pw.tw.open()
 	pw.inText = true
 }
@@ -823,6 +1009,45 @@ func (pw *PageWriter) tab() {
 	// TODO: move to next horizontal tab position or print space
 }
 
+// TextAngle returns the angle, in degrees counterclockwise, at which text is printed.
+func (pw *PageWriter) TextAngle() float64 {
+	return pw.textAngle
+}
+
+// textDirection returns the cosine and sine of the text angle, giving the direction of the baseline.
+func (pw *PageWriter) textDirection() (cos, sin float64) {
+	if pw.textAngle == 0 {
+		return 1, 0
+	}
+	sin, cos = math.Sincos(pw.textAngle * math.Pi / 180)
+	return
+}
+
+// textRect returns the rectangle on the page, in default coordinates, bounding text between loc1 and loc2
+// on the baseline, extending from descent to ascent.
+func (pw *PageWriter) textRect(loc1, loc2 Location, descent, ascent float64) *rectangle {
+	cos, sin := pw.textDirection()
+	corners := []Location{
+		{loc1.X - sin*descent, loc1.Y + cos*descent},
+		{loc2.X - sin*descent, loc2.Y + cos*descent},
+		{loc2.X - sin*ascent, loc2.Y + cos*ascent},
+		{loc1.X - sin*ascent, loc1.Y + cos*ascent},
+	}
+	return pw.pageRect(corners...)
+}
+
+// pageRect returns the rectangle on the page, in default coordinates, bounding corners given in points
+// in the current coordinate system.
+func (pw *PageWriter) pageRect(corners ...Location) *rectangle {
+	rect := &rectangle{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}
+	for _, c := range corners {
+		x, y := pw.ctm.apply(c.X, c.Y)
+		rect.x1, rect.y1 = math.Min(rect.x1, x), math.Min(rect.y1, y)
+		rect.x2, rect.y2 = math.Max(rect.x2, x), math.Max(rect.y2, y)
+	}
+	return rect
+}
+
 func (pw *PageWriter) translate(y float64) float64 {
 	return pw.pageHeight - y
 }
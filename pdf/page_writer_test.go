@@ -5,6 +5,7 @@ package pdf
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/rowland/leadtype/afm_fonts"
@@ -14,9 +15,9 @@ import (
 )
 
 const (
-	black = 0
-	red   = 0xFF0000
-	green = 0x00FF00
+	black = colors.Color(0)
+	red   = colors.Color(0xFF0000)
+	green = colors.Color(0x00FF00)
 )
 
 func TestPageWriter_checkSetFillColor(t *testing.T) {
@@ -416,6 +417,40 @@ func TestPageWriter_startGraph(t *testing.T) {
 	check(t, pw.last.loc.equal(Location{0, 0}), "startGraph should reset location")
 }
 
+func TestPageWriter_TextAngle(t *testing.T) {
+	fc, err := afm_fonts.New("../afm/data/fonts/*.afm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dw := NewDocWriter()
+	dw.AddFontSource(fc)
+	pw := newPageWriter(dw, options.Options{})
+	pw.SetFont("Helvetica", 10, options.Options{})
+
+	check(t, pw.TextAngle() == 0, "Should default to zero")
+	pw.MoveTo(100, 100)
+	prev := pw.SetTextAngle(90)
+	check(t, prev == 0, "Previous angle was zero")
+	pw.SetUnderline(true)
+	pw.Print("Hello")
+	pw.flushText()
+	width := pw.loc.Y - 692
+	check(t, width > 0, "Cursor should advance up the page")
+	expectF(t, 100, pw.loc.X)
+	pw.endText()
+	s := pw.stream.String()
+	check(t, strings.Contains(s, "0 1 -1 0 100 692 Tm\n"), "Should set text matrix for angle")
+	check(t, strings.Contains(s, "(Hello) Tj\n"), "Should show text")
+	// Underline is drawn right of the rotated baseline, 1 point below it in text space.
+	check(t, strings.Contains(s, "101 692 m\n101 "+g(692+width)+" l\n"), "Underline should follow the baseline")
+
+	pw.SetTextAngle(0)
+	pw.PrintWithOptions("Diagonal", options.Options{"angle": 45})
+	check(t, pw.TextAngle() == 0, "Option should not change the text angle")
+	pw.endText()
+	check(t, strings.Contains(pw.stream.String(), "Tm\n(Diagonal) Tj\n"), "Should set text matrix for angle option")
+}
+
 func TestPageWriter_translate(t *testing.T) {
 	dw := NewDocWriter()
 	pw := newPageWriter(dw, options.Options{})
@@ -0,0 +1,99 @@
+// Copyright 2015 Brent Rowland.
+// Use of this source code is governed the Apache License, Version 2.0, as described in the LICENSE file.
+
+package pdf
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rowland/leadtype/colors"
+)
+
+// deviceComponents returns the device colour space in which to paint p, with the components of p
+// and of white in it. Paints other than gray levels and CMYK are painted in RGB, as is CMYK in PDF/A documents,
+// whose output intent is RGB.
+func (dw *DocWriter) deviceComponents(p colors.Paint) (space string, components, white []float64) {
+	switch p := p.(type) {
+	case colors.GrayLevel:
+		return "DeviceGray", []float64{float64(p)}, []float64{1}
+	case colors.CMYK:
+		if !dw.pdfa {
+			return "DeviceCMYK", []float64{p.C, p.M, p.Y, p.K}, []float64{0, 0, 0, 0}
+		}
+	}
+	r, g, b := p.RGB64()
+	return "DeviceRGB", []float64{r, g, b}, []float64{1, 1, 1}
+}
+
+// separationSpace returns a Separation colour space for the colorant of spot, whose tint transform blends
+// from white to its alternate.
+func (dw *DocWriter) separationSpace(spot colors.Spot) array {
+	space, components, white := dw.deviceComponents(spot.Alternate)
+	tintTransform := dictionary{
+		"FunctionType": integer(2),
+		"Domain":       realArray(0, 1),
+		"C0":           realArray(white...),
+		"C1":           realArray(components...),
+		"N":            integer(1),
+	}
+	return array{name("Separation"), name(spot.Name), name(space), tintTransform}
+}
+
+// separation returns the name of the colour space resource for the colorant of spot, creating it on first use.
+func (dw *DocWriter) separation(spot colors.Spot) string {
+	key := colors.Spot{Name: spot.Name, Alternate: spot.Alternate, Tint: 1}
+	if name, ok := dw.separations[key]; ok {
+		return name
+	}
+	name := fmt.Sprintf("CS%d", len(dw.separations))
+	dw.resources.setColorSpace(name, dw.separationSpace(spot))
+	dw.separations[key] = name
+	return name
+}
+
+// uncoloredPatternSpace returns the name of the colour space in which uncoloured patterns are painted in p,
+// with the components of p in it.
+func (dw *DocWriter) uncoloredPatternSpace(p colors.Paint) (string, []float64) {
+	if spot, ok := p.(colors.Spot); ok {
+		cs := dw.separation(spot)
+		dw.resources.setColorSpace("Pattern"+cs, array{name("Pattern"), dw.resources.colorSpaces[cs]})
+		return "Pattern" + cs, []float64{spot.Tint}
+	}
+	space, components, _ := dw.deviceComponents(p)
+	patternSpace := "Pattern" + strings.TrimPrefix(space, "Device")
+	dw.resources.setColorSpace(patternSpace, array{name("Pattern"), name(space)})
+	return patternSpace, components
+}
+
+func (pw *PageWriter) setPaintFill(p colors.Paint) {
+	if spot, ok := p.(colors.Spot); ok {
+		pw.mw.setColorSpaceFill(pw.dw.separation(spot))
+		pw.mw.setColorFill([]float64{spot.Tint})
+		return
+	}
+	switch space, c, _ := pw.dw.deviceComponents(p); space {
+	case "DeviceGray":
+		pw.mw.setGrayFill(c[0])
+	case "DeviceCMYK":
+		pw.mw.setCmykColorFill(c[0], c[1], c[2], c[3])
+	default:
+		pw.mw.setRgbColorFill(c[0], c[1], c[2])
+	}
+}
+
+func (pw *PageWriter) setPaintStroke(p colors.Paint) {
+	if spot, ok := p.(colors.Spot); ok {
+		pw.mw.setColorSpaceStroke(pw.dw.separation(spot))
+		pw.mw.setColorStroke([]float64{spot.Tint})
+		return
+	}
+	switch space, c, _ := pw.dw.deviceComponents(p); space {
+	case "DeviceGray":
+		pw.mw.setGrayStroke(c[0])
+	case "DeviceCMYK":
+		pw.mw.setCmykColorStroke(c[0], c[1], c[2], c[3])
+	default:
+		pw.mw.setRgbColorStroke(c[0], c[1], c[2])
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2015 Brent Rowland.
+// Use of this source code is governed the Apache License, Version 2.0, as described in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/rowland/leadtype/afm_fonts"
+	"github.com/rowland/leadtype/colors"
+	"github.com/rowland/leadtype/options"
+)
+
+func TestPageWriter_checkSetFillColor_paints(t *testing.T) {
+	dw := NewDocWriter()
+	pw := newPageWriter(dw, options.Options{})
+	pw.SetFillColor(colors.GrayLevel(0))
+	pw.checkSetFillColor()
+	expectS(t, "0 g\n", pw.stream.String())
+
+	pw.stream.Reset()
+	pw.SetFillColor(colors.CMYK{C: 1, Y: 0.5})
+	pw.checkSetFillColor()
+	pw.SetFillColor("cmyk(100%, 0, 50%, 0)")
+	pw.checkSetFillColor()
+	expectS(t, "1 0 0.5 0 k\n", pw.stream.String())
+
+	pw.stream.Reset()
+	pw.SetFillColor(colors.Black)
+	pw.checkSetFillColor()
+	expectS(t, "0 0 0 rg\n", pw.stream.String())
+
+	pw.stream.Reset()
+	gold := colors.Spot{Name: "Gold", Alternate: colors.CMYK{M: 0.2, Y: 0.6, K: 0.2}, Tint: 1}
+	pw.SetFillColor(gold)
+	pw.checkSetFillColor()
+	gold.Tint = 0.5
+	pw.SetFillColor(gold)
+	pw.checkSetFillColor()
+	expectS(t, "/CS0 cs\n1 sc\n/CS0 cs\n0.5 sc\n", pw.stream.String())
+	expectI(t, 1, len(dw.separations))
+}
+
+func TestPageWriter_checkSetLineColor_paints(t *testing.T) {
+	dw := NewDocWriter()
+	pw := newPageWriter(dw, options.Options{})
+	pw.SetLineColor(colors.GrayLevel(0.5))
+	pw.checkSetLineColor()
+	pw.SetLineColor(colors.CMYK{K: 1})
+	pw.checkSetLineColor()
+	pw.SetLineColor(colors.Spot{Name: "Silver", Alternate: colors.GrayLevel(0.75), Tint: 0.25})
+	pw.checkSetLineColor()
+	expectS(t, "0.5 G\n0 0 0 1 K\n/CS0 CS\n0.25 SC\n", pw.stream.String())
+}
+
+func TestPageWriter_Print_paint(t *testing.T) {
+	fc, err := afm_fonts.New("../afm/data/fonts/*.afm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dw := NewDocWriter()
+	dw.AddFontSource(fc)
+	pw := newPageWriter(dw, options.Options{})
+	pw.SetFont("Helvetica", 12, options.Options{"color": colors.CMYK{M: 1}})
+	check(t, pw.FontColor() == colors.CMYK{M: 1}, "Font color should be CMYK.")
+	pw.Print("Magenta")
+	pw.SetFontColor(colors.GrayLevel(0))
+	pw.Print(" and black")
+	pw.endText()
+	s := pw.stream.String()
+	check(t, strings.Contains(s, "0 1 0 0 k\n/F0 12 Tf\n(Magenta) Tj\n"), "Text should be printed in CMYK.")
+	check(t, strings.Contains(s, "0 g\n( and black) Tj\n"), "Text should be printed in gray.")
+}
+
+func TestSeparationSpace(t *testing.T) {
+	var buf bytes.Buffer
+	NewDocWriter().separationSpace(colors.Spot{Name: "PANTONE 185 C", Alternate: colors.CMYK{M: 0.91, Y: 0.76}}).write(&buf)
+	expectS(t, "[/Separation /PANTONE#20185#20C /DeviceCMYK <<\n/C0 [0 0 0 0 ] \n/C1 [0 0.91 0.76 0 ] \n/Domain [0 1 ] \n/FunctionType 2 \n/N 1 \n>>\n] ", buf.String())
+}
+
+func TestDocWriter_uncoloredPatternSpace(t *testing.T) {
+	dw := NewDocWriter()
+	dw.SetCompressionLevel(NoCompression)
+	dw.NewPage()
+	hatch, _ := dw.Hatch("cross", 6, 1)
+	dw.SetFillColor(colors.CMYK{C: 1})
+	dw.SetFillColor(hatch)
+	dw.Rectangle(72, 72, 72, 72, false, true)
+	dw.SetFillColor(colors.Spot{Name: "Gold", Alternate: colors.CMYK{M: 0.2, Y: 0.6, K: 0.2}, Tint: 0.5})
+	dw.SetFillColor(hatch)
+	dw.Rectangle(72, 144, 72, 72, false, true)
+	var buf bytes.Buffer
+	_, err := dw.WriteTo(&buf)
+	checkFatal(t, err == nil, "WriteTo should succeed.")
+	pdf := buf.String()
+	check(t, strings.Contains(pdf, "/PatternCMYK cs\n1 0 0 0 /P0 scn\n"), "Pattern should be painted in CMYK.")
+	check(t, strings.Contains(pdf, "/PatternCS0 cs\n0.5 /P0 scn\n"), "Pattern should be painted in spot colour.")
+	check(t, strings.Contains(pdf, "/PatternCMYK [/Pattern /DeviceCMYK ] "), "CMYK pattern space should be in resources.")
+	check(t, strings.Contains(pdf, "/PatternCS0 [/Pattern [/Separation /Gold "), "Spot pattern space should be in resources.")
+	checkXRefTable(t, pdf)
+}
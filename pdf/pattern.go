@@ -0,0 +1,183 @@
+// Copyright 2015 Brent Rowland.
+// Use of this source code is governed the Apache License, Version 2.0, as described in the LICENSE file.
+
+package pdf
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	errInvalidHatch = errors.New("Invalid hatch style.")
+	errNoPattern    = errors.New("No pattern begun.")
+)
+
+// Pattern is a cell of content, returned by EndPattern, copies of which are laid side by side to fill shapes
+// in place of a single colour when passed to SetFillColor.
+type Pattern struct {
+	id            int
+	colored       bool
+	width, height float64
+	data          []byte
+}
+
+// Colored returns true if the pattern paints its own colours, or false if it is painted in the fill colour.
+func (p *Pattern) Colored() bool {
+	return p.colored
+}
+
+// HatchMap holds hatch styles by name, each drawing one square cell of its hatch, spacing wide, with pw.
+type HatchMap map[string]func(pw *PageWriter, spacing float64)
+
+func (hm HatchMap) Add(name string, draw func(pw *PageWriter, spacing float64)) {
+	hm[name] = draw
+}
+
+var Hatches = HatchMap{
+	"horizontal":    hatchHorizontal,
+	"vertical":      hatchVertical,
+	"diagonal":      hatchDiagonal,
+	"backdiagonal":  hatchBackDiagonal,
+	"cross":         func(pw *PageWriter, s float64) { hatchHorizontal(pw, s); hatchVertical(pw, s) },
+	"diagonalcross": func(pw *PageWriter, s float64) { hatchDiagonal(pw, s); hatchBackDiagonal(pw, s) },
+	"dots":          hatchDots,
+}
+
+func hatchHorizontal(pw *PageWriter, s float64) {
+	pw.MoveTo(0, s/2)
+	pw.LineTo(s, s/2)
+}
+
+func hatchVertical(pw *PageWriter, s float64) {
+	pw.MoveTo(s/2, 0)
+	pw.LineTo(s/2, s)
+}
+
+// Diagonal lines run corner to corner, with lines either side filling the corners clipped from neighbouring cells.
+
+func hatchDiagonal(pw *PageWriter, s float64) {
+	for x := -s; x <= s; x += s {
+		pw.MoveTo(x, s)
+		pw.LineTo(x+s, 0)
+	}
+}
+
+func hatchBackDiagonal(pw *PageWriter, s float64) {
+	for x := -s; x <= s; x += s {
+		pw.MoveTo(x, 0)
+		pw.LineTo(x+s, s)
+	}
+}
+
+func hatchDots(pw *PageWriter, s float64) {
+	pw.Circle(s/2, s/2, pw.LineWidth(pw.Units())/2, false, true, false)
+}
+
+// BeginPattern starts the cell of a pattern, width by height in the document's units, returning a PageWriter
+// with which to draw it. Until EndPattern is called, the DocWriter's drawing methods also draw on the cell.
+// The colours set while drawing an uncoloured pattern are ignored, so that it is painted in the fill colour instead.
+func (dw *DocWriter) BeginPattern(width, height float64, colored bool) *PageWriter {
+	pw := dw.BeginTemplate(width, height)
+	pw.cell = &Pattern{colored: colored, width: pw.pageWidth, height: pw.pageHeight}
+	// Opacity and blending apply to the pattern as painted, so the cell is drawn without them unless set.
+	pw.initTransparency()
+	return pw
+}
+
+// EndPattern finishes the pattern cell most recently begun, returning the Pattern with which to fill shapes.
+func (dw *DocWriter) EndPattern() (*Pattern, error) {
+	n := len(dw.templates)
+	if n == 0 || dw.templates[n-1].cell == nil {
+		return nil, errNoPattern
+	}
+	pw := dw.templates[n-1]
+	dw.templates = dw.templates[:n-1]
+	pw.endText()
+	pw.endGraph()
+	pw.restoreStates()
+	pw.isClosed = true
+	cell := pw.cell
+	cell.data = append([]byte(nil), pw.stream.Bytes()...)
+	pw.stream.Reset()
+	cell.id = len(dw.cells)
+	dw.cells = append(dw.cells, cell)
+	return cell, nil
+}
+
+// Hatch returns an uncoloured pattern of lines or dots in style, spacing apart and width thick, in the document's units.
+// The styles are those in Hatches: horizontal, vertical, diagonal, backdiagonal, cross, diagonalcross and dots,
+// whose diameter is width.
+func (dw *DocWriter) Hatch(style string, spacing, width float64) (*Pattern, error) {
+	draw, ok := Hatches[style]
+	if !ok {
+		return nil, errInvalidHatch
+	}
+	units := dw.options.StringDefault("units", "pt")
+	key := fmt.Sprint(style, spacing, width, units)
+	if hatch, ok := dw.hatches[key]; ok {
+		return hatch, nil
+	}
+	pw := dw.BeginPattern(spacing, spacing, false)
+	pw.SetLineWidth(width, units)
+	draw(pw, spacing)
+	hatch, err := dw.EndPattern()
+	if err == nil {
+		dw.hatches[key] = hatch
+	}
+	return hatch, err
+}
+
+// tilingPattern returns the name of a pattern repeating the cell of p on pw in its current coordinate system,
+// creating it on first use.
+func (pw *PageWriter) tilingPattern(p *Pattern) string {
+	key := fmt.Sprint("tiling", p.id, pw.ctm)
+	if name, ok := pw.dw.patterns[key]; ok {
+		return name
+	}
+	m := pw.ctm
+	pattern := newTilingPattern(pw.dw.nextSeq(), 0, p.colored, &rectangle{0, 0, p.width, p.height}, p.data)
+	pattern.setMatrix(realArray(m.A, m.B, m.C, m.D, m.E, m.F))
+	pattern.setResources(&indirectObjectRef{pw.dw.resources})
+	pw.dw.compress(&pattern.stream)
+	pw.dw.file.body.add(pattern)
+	return pw.dw.addPattern(key, pattern)
+}
+
+// colorless returns true while drawing the cell of an uncoloured pattern, which must not set colours.
+func (pw *PageWriter) colorless() bool {
+	return pw.cell != nil && !pw.cell.colored
+}
+
+func (pw *PageWriter) checkSetFillPattern() {
+	p := pw.fillPattern
+	name := pw.tilingPattern(p)
+	if name == pw.last.fillPatternName && (p.colored || pw.fillColor == pw.last.fillColor) {
+		return
+	}
+	if pw.inPath && pw.autoPath {
+		pw.gw.stroke()
+		pw.inPath = false
+	}
+	if p.colored {
+		pw.mw.setColorSpaceFill("Pattern")
+		pw.mw.setPatternFill(name, nil)
+		// The fill colour must be set again after the pattern.
+		pw.last.fillColor = nil
+	} else {
+		space, components := pw.dw.uncoloredPatternSpace(pw.fillColor)
+		pw.mw.setColorSpaceFill(space)
+		pw.mw.setPatternFill(name, components)
+		pw.last.fillColor = pw.fillColor
+	}
+	pw.last.fillPatternName = name
+}
+
+// FillPattern returns the pattern set by SetFillColor, or nil if not filling with a pattern.
+func (pw *PageWriter) FillPattern() *Pattern {
+	return pw.fillPattern
+}
+
+func (dw *DocWriter) FillPattern() *Pattern {
+	return dw.CurPage().FillPattern()
+}
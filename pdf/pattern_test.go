@@ -0,0 +1,80 @@
+// Copyright 2015 Brent Rowland.
+// Use of this source code is governed the Apache License, Version 2.0, as described in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/rowland/leadtype/colors"
+)
+
+func TestDocWriter_BeginPattern(t *testing.T) {
+	dw := NewDocWriter()
+	dw.NewPage()
+	pw := dw.BeginPattern(10, 10, false)
+	check(t, dw.CurPage() == pw, "Pattern cell should be current page.")
+	_, err := dw.EndTemplate()
+	check(t, err == errNoTemplate, "EndTemplate should not end pattern.")
+	dw.SetLineColor(colors.Red)
+	dw.SetFillColor(colors.Blue)
+	dw.MoveTo(0, 5)
+	dw.LineTo(10, 5)
+	dw.Rectangle(2, 2, 6, 6, false, true)
+	p, err := dw.EndPattern()
+	checkFatal(t, err == nil, "EndPattern should succeed.")
+	check(t, !p.Colored(), "Pattern should be uncoloured.")
+	expectS(t, "0 5 m\n10 5 l\nS\n2 2 6 6 re\nf\n", string(p.data))
+	_, err = dw.EndPattern()
+	check(t, err == errNoPattern, "EndPattern should need a pattern begun.")
+}
+
+func TestDocWriter_SetFillColor_pattern(t *testing.T) {
+	dw := NewDocWriter()
+	dw.SetCompressionLevel(NoCompression)
+	dw.NewPage()
+	dw.BeginPattern(20, 20, true)
+	dw.SetFillColor(colors.Red)
+	dw.Rectangle(0, 0, 10, 10, false, true)
+	checker, _ := dw.EndPattern()
+	hatch, err := dw.Hatch("diagonal", 6, 1)
+	checkFatal(t, err == nil, "Hatch should succeed.")
+	again, _ := dw.Hatch("diagonal", 6, 1)
+	check(t, hatch == again, "Hatch should be reused.")
+
+	dw.SetFillColor(checker)
+	check(t, dw.FillPattern() == checker, "Fill pattern should be set.")
+	dw.Rectangle(72, 72, 144, 72, false, true)
+	dw.SetFillColor(colors.Blue)
+	check(t, dw.FillPattern() == nil, "Setting a colour should replace the pattern.")
+	dw.SetFillColor(hatch)
+	dw.Rectangle(72, 144, 144, 72, false, true)
+	dw.SetFillColor(colors.Blue)
+	dw.Rectangle(72, 216, 144, 72, false, true)
+	var buf bytes.Buffer
+	_, err = dw.WriteTo(&buf)
+	checkFatal(t, err == nil, "WriteTo should succeed.")
+	pdf := buf.String()
+	check(t, strings.Contains(pdf, "/Pattern cs\n/P0 scn\n"), "Coloured pattern should be set without colour.")
+	check(t, strings.Contains(pdf, "/PatternRGB cs\n0 0 1 /P1 scn\n"), "Uncoloured pattern should be set with fill colour.")
+	check(t, strings.Contains(pdf, "/PatternRGB [/Pattern /DeviceRGB ] "), "Pattern colour space should be in resources.")
+	check(t, strings.Contains(pdf, "/PaintType 1 "), "Coloured pattern should paint its own colours.")
+	check(t, strings.Contains(pdf, "/PaintType 2 "), "Hatch should be uncoloured.")
+	check(t, strings.Contains(pdf, "/XStep 6 "), "Hatch should repeat at its spacing.")
+	check(t, strings.Contains(pdf, "0 0 1 rg\n72 504 144 72 re\n"), "Colour should be set after pattern.")
+	checkXRefTable(t, pdf)
+}
+
+func TestDocWriter_Hatch(t *testing.T) {
+	dw := NewDocWriter()
+	_, err := dw.Hatch("plaid", 6, 1)
+	check(t, err == errInvalidHatch, "Hatch should need a known style.")
+	for style := range Hatches {
+		p, err := dw.Hatch(style, 6, 1)
+		check(t, err == nil, "Hatch should succeed.")
+		check(t, len(p.data) > 0, "Hatch should draw its cell.")
+		check(t, !strings.Contains(string(p.data), "rg"), "Hatch should not set colours.")
+	}
+}
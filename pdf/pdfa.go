@@ -0,0 +1,56 @@
+// Copyright 2015 Brent Rowland.
+// Use of this source code is governed the Apache License, Version 2.0, as described in the LICENSE file.
+
+package pdf
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/rowland/leadtype/font"
+)
+
+var (
+	errPDFATooLate    = errors.New("PDF/A conformance must be set before any fonts are used or the first page is written.")
+	errPDFAEncryption = errors.New("PDF/A documents cannot be encrypted.")
+	errPDFACMYKImage  = errors.New("CMYK images cannot be used in PDF/A documents, whose output intent is sRGB.")
+)
+
+// SetPDFA determines whether the document is written to conform to PDF/A-2b, for long-term archiving.
+// Every font is then embedded, and fonts that cannot be, such as the standard Type1 fonts, are rejected
+// by AddFont and SetFont. Encryption is not permitted. Colours are painted in sRGB, the document's output intent,
+// with CMYK paints converted to RGB, while transparency is left as is, as PDF/A-2 allows it.
+func (dw *DocWriter) SetPDFA(pdfa bool) (prev bool, err error) {
+	if dw.file.out != nil || len(dw.fontKeys) > 0 {
+		return dw.pdfa, errPDFATooLate
+	}
+	if pdfa && dw.security != nil {
+		return dw.pdfa, errPDFAEncryption
+	}
+	prev = dw.pdfa
+	dw.pdfa = pdfa
+	// PDF/A requires a comment of binary characters after the header.
+	dw.file.header.binary = pdfa
+	return
+}
+
+// PDFA reports whether the document is written to conform to PDF/A-2b.
+func (dw *DocWriter) PDFA() bool {
+	return dw.pdfa
+}
+
+// checkEmbeddable returns an error if f cannot be embedded in a PDF/A document.
+func (dw *DocWriter) checkEmbeddable(f *font.Font) error {
+	if dw.pdfa && (f.SubType() != "TrueType" || !f.Embeddable()) {
+		return fmt.Errorf("Font %s cannot be embedded, as PDF/A requires.", f.Family())
+	}
+	return nil
+}
+
+// writeOutputIntent adds the sRGB output intent of a PDF/A document, with its ICC profile.
+func (dw *DocWriter) writeOutputIntent() {
+	profile := newICCProfileStream(dw.nextSeq(), 0, 3, srgbProfile)
+	dw.compress(profile)
+	dw.file.body.add(profile)
+	dw.catalog.setOutputIntents(array{newOutputIntent("GTS_PDFA1", srgbIdentifier, profile)})
+}
@@ -0,0 +1,146 @@
+// Copyright 2015 Brent Rowland.
+// Use of this source code is governed the Apache License, Version 2.0, as described in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/rowland/leadtype/afm_fonts"
+	"github.com/rowland/leadtype/codepage"
+	"github.com/rowland/leadtype/colors"
+	"github.com/rowland/leadtype/options"
+)
+
+func TestDocWriter_SetPDFA(t *testing.T) {
+	dw := NewDocWriter()
+	prev, err := dw.SetPDFA(true)
+	check(t, !prev && err == nil, "SetPDFA should succeed.")
+	check(t, dw.PDFA(), "PDF/A should be set.")
+	check(t, dw.SetEncryption(AES_128, "", "secret", PermitPrint) == errPDFAEncryption, "PDF/A should forbid encryption.")
+	check(t, dw.SetEncryption(NoEncryption, "", "", 0) == nil, "PDF/A should allow no encryption.")
+	dw.SetCompressionLevel(NoCompression)
+	dw.SetInfo(Info{Title: "Archive"})
+	dw.NewPage()
+	dw.SetFillColor(colors.CMYK{C: 1, Y: 1})
+	dw.SetFillOpacity(0.5)
+	dw.Rectangle(72, 72, 144, 72, false, true)
+
+	var buf bytes.Buffer
+	_, err = dw.WriteTo(&buf)
+	checkFatal(t, err == nil, "WriteTo should succeed.")
+	pdf := buf.String()
+	check(t, strings.HasPrefix(pdf, "%PDF-1.4\n%\xE2\xE3\xCF\xD3\n"), "Header should be followed by binary comment.")
+	check(t, strings.Contains(pdf, "/OutputIntents [<<\n/DestOutputProfile "), "Catalog should have output intent.")
+	check(t, strings.Contains(pdf, "/OutputConditionIdentifier (sRGB IEC61966-2.1) "), "Output intent should be sRGB.")
+	check(t, strings.Contains(pdf, "/S /GTS_PDFA1 "), "Output intent should be for PDF/A.")
+	check(t, strings.Contains(pdf, "/N 3 "), "Profile should have three components.")
+	check(t, strings.Contains(pdf, "<pdfaid:part>2</pdfaid:part>"), "Metadata should identify PDF/A.")
+	check(t, strings.Contains(pdf, "/ModDate "), "Info should have modification date.")
+	check(t, strings.Contains(pdf, "<xmp:ModifyDate>"), "Metadata should have modification date.")
+	check(t, strings.Contains(pdf, "0 1 0 rg\n"), "CMYK should be painted in RGB.")
+	check(t, !strings.Contains(pdf, " k\n"), "CMYK should not be painted.")
+	check(t, strings.Contains(pdf, "/ca 0.5 "), "Transparency should be kept.")
+	check(t, !strings.Contains(pdf, "/Encrypt "), "Document should not be encrypted.")
+	checkXRefTable(t, pdf)
+}
+
+func TestDocWriter_SetPDFA_metadata(t *testing.T) {
+	dw := NewDocWriter()
+	dw.SetPDFA(true)
+	dw.NewPage()
+	var buf bytes.Buffer
+	_, err := dw.WriteTo(&buf)
+	checkFatal(t, err == nil, "WriteTo should succeed.")
+	check(t, strings.Contains(buf.String(), "<pdfaid:conformance>B</pdfaid:conformance>"), "Metadata should not be compressed.")
+}
+
+func TestDocWriter_SetPDFA_tooLate(t *testing.T) {
+	dw := NewDocWriter()
+	checkFatal(t, dw.SetEncryption(RC4_128, "", "secret", PermitPrint) == nil, "SetEncryption should succeed.")
+	_, err := dw.SetPDFA(true)
+	check(t, err == errPDFAEncryption, "PDF/A should not be set on an encrypted document.")
+	check(t, !dw.PDFA(), "PDF/A should not be set.")
+
+	fc, err := afm_fonts.New("../afm/data/fonts/*.afm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dw = NewDocWriter()
+	dw.AddFontSource(fc)
+	fonts, err := dw.AddFont("Helvetica", options.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dw.fontKey(fonts[0], codepage.Idx_CP1252)
+	_, err = dw.SetPDFA(true)
+	check(t, err == errPDFATooLate, "PDF/A should be set before fonts are used.")
+}
+
+func TestDocWriter_checkEmbeddable(t *testing.T) {
+	fc, err := afm_fonts.New("../afm/data/fonts/*.afm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dw := NewDocWriter()
+	dw.AddFontSource(fc)
+	fonts, err := dw.AddFont("Helvetica", options.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(t, dw.checkEmbeddable(fonts[0]) == nil, "Fonts need not be embedded outside PDF/A.")
+	dw.SetPDFA(true)
+	_, err = dw.fontKey(fonts[0], codepage.Idx_CP1252)
+	checkFatal(t, err != nil, "PDF/A should reject fonts that cannot be embedded.")
+	expectS(t, "Font Helvetica cannot be embedded, as PDF/A requires.", err.Error())
+	_, err = dw.compositeFontKey(fonts[0])
+	check(t, err != nil, "PDF/A should reject composite fonts that cannot be embedded.")
+}
+
+func TestDocWriter_SetFont_pdfa(t *testing.T) {
+	fc, err := afm_fonts.New("../afm/data/fonts/*.afm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dw := NewDocWriter()
+	dw.SetPDFA(true)
+	dw.AddFontSource(fc)
+	_, err = dw.SetFont("Helvetica", 12, options.Options{})
+	checkFatal(t, err != nil, "SetFont should reject fonts that cannot be embedded.")
+	expectS(t, "Font Helvetica cannot be embedded, as PDF/A requires.", err.Error())
+	check(t, len(dw.Fonts()) == 0, "Font should not be set.")
+
+	dw = NewDocWriter()
+	dw.SetPDFA(true)
+	dw.AddFontSource(restrictedFontSource{fc})
+	_, err = dw.AddFont("Helvetica", options.Options{})
+	check(t, err != nil, "AddFont should reject TrueType fonts not licensed for embedding.")
+}
+
+func TestDocWriter_SetPDFA_link(t *testing.T) {
+	dw := NewDocWriter()
+	dw.SetPDFA(true)
+	dw.SetCompressionLevel(NoCompression)
+	dw.NewPage()
+	dw.AddLink(72, 72, 72, 18, "http://example.com/")
+	dw.AddPageLink(72, 144, 72, 18, 1, 0)
+	var buf bytes.Buffer
+	_, err := dw.WriteTo(&buf)
+	checkFatal(t, err == nil, "WriteTo should succeed.")
+	pdf := buf.String()
+	expectI(t, 2, strings.Count(pdf, "/Subtype /Link "))
+	expectI(t, 2, strings.Count(pdf, "/F 4 \n/Rect "))
+	checkXRefTable(t, pdf)
+}
+
+func TestSRGBProfile(t *testing.T) {
+	expectI(t, len(srgbProfile), int(binary.BigEndian.Uint32(srgbProfile)))
+	expectS(t, "mntrRGB XYZ ", string(srgbProfile[12:24]))
+	expectS(t, "acsp", string(srgbProfile[36:40]))
+	expectS(t, "d7517e43d27888dcf0548e1aa4a53460", fmt.Sprintf("%x", md5.Sum(srgbProfile)))
+}
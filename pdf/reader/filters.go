@@ -0,0 +1,168 @@
+// Copyright 2015 Brent Rowland.
+// Use of this source code is governed the Apache License, Version 2.0, as described in the LICENSE file.
+
+package reader
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/ascii85"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+var errTruncatedPredictor = errors.New("Predicted data ends within a row.")
+
+// Decode returns the stream's data with its filters removed.
+// FlateDecode, with or without PNG predictors, ASCIIHexDecode and ASCII85Decode are supported.
+func (s *Stream) Decode() (data []byte, err error) {
+	filters, parms := s.filters()
+	data = s.Raw
+	for i, filter := range filters {
+		switch filter {
+		case "FlateDecode", "Fl":
+			data, err = flateDecode(data, parms[i])
+		case "ASCIIHexDecode", "AHx":
+			data, err = asciiHexDecode(data)
+		case "ASCII85Decode", "A85":
+			data, err = ascii85Decode(data)
+		default:
+			err = fmt.Errorf("Unsupported filter %s.", filter)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return
+}
+
+func (s *Stream) filters() (filters []Name, parms []Dictionary) {
+	switch f := s.Dict["Filter"].(type) {
+	case Name:
+		filters = []Name{f}
+	case Array:
+		for _, obj := range f {
+			if n, ok := obj.(Name); ok {
+				filters = append(filters, n)
+			}
+		}
+	}
+	parms = make([]Dictionary, len(filters))
+	switch p := s.Dict["DecodeParms"].(type) {
+	case Dictionary:
+		if len(parms) > 0 {
+			parms[0] = p
+		}
+	case Array:
+		for i, obj := range p {
+			if d, ok := obj.(Dictionary); ok && i < len(parms) {
+				parms[i] = d
+			}
+		}
+	}
+	return
+}
+
+func flateDecode(data []byte, parms Dictionary) ([]byte, error) {
+	zr, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	data, err = ioutil.ReadAll(zr)
+	// Tolerate streams truncated before their checksum, as other readers do.
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	return unpredict(data, parms)
+}
+
+func intParm(parms Dictionary, key string, def int) int {
+	if i, ok := parms[key].(Integer); ok {
+		return int(i)
+	}
+	return def
+}
+
+// unpredict reverses the PNG predictors used with FlateDecode, typically by cross-reference streams.
+func unpredict(data []byte, parms Dictionary) ([]byte, error) {
+	predictor := intParm(parms, "Predictor", 1)
+	if predictor == 1 {
+		return data, nil
+	}
+	if predictor < 10 {
+		return nil, fmt.Errorf("Unsupported predictor %d.", predictor)
+	}
+	colors := intParm(parms, "Colors", 1)
+	bpc := intParm(parms, "BitsPerComponent", 8)
+	columns := intParm(parms, "Columns", 1)
+	bpp := (colors*bpc + 7) / 8
+	rowLen := (colors*bpc*columns + 7) / 8
+	if len(data)%(rowLen+1) != 0 {
+		return nil, errTruncatedPredictor
+	}
+	out := make([]byte, 0, len(data)/(rowLen+1)*rowLen)
+	prev := make([]byte, rowLen)
+	for len(data) > 0 {
+		tag, row := data[0], data[1:rowLen+1]
+		data = data[rowLen+1:]
+		for i := range row {
+			var left, upLeft byte
+			if i >= bpp {
+				left, upLeft = row[i-bpp], prev[i-bpp]
+			}
+			switch tag {
+			case 0:
+			case 1:
+				row[i] += left
+			case 2:
+				row[i] += prev[i]
+			case 3:
+				row[i] += byte((int(left) + int(prev[i])) / 2)
+			case 4:
+				row[i] += paeth(left, prev[i], upLeft)
+			default:
+				return nil, fmt.Errorf("Unsupported PNG filter type %d.", tag)
+			}
+		}
+		out = append(out, row...)
+		prev = row
+	}
+	return out, nil
+}
+
+func paeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := abs(p-int(a)), abs(p-int(b)), abs(p-int(c))
+	if pa <= pb && pa <= pc {
+		return a
+	}
+	if pb <= pc {
+		return b
+	}
+	return c
+}
+
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
+
+func asciiHexDecode(data []byte) ([]byte, error) {
+	lx := newLexer(io.MultiReader(bytes.NewReader(data), bytes.NewReader([]byte(">"))), 0)
+	tok, err := lx.hexString()
+	return []byte(tok.s), err
+}
+
+func ascii85Decode(data []byte) ([]byte, error) {
+	data = bytes.TrimSpace(data)
+	data = bytes.TrimPrefix(data, []byte("<~"))
+	if i := bytes.Index(data, []byte("~>")); i >= 0 {
+		data = data[:i]
+	}
+	return ioutil.ReadAll(ascii85.NewDecoder(bytes.NewReader(data)))
+}
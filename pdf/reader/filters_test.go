@@ -0,0 +1,64 @@
+// Copyright 2015 Brent Rowland.
+// Use of this source code is governed the Apache License, Version 2.0, as described in the LICENSE file.
+
+package reader
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"testing"
+)
+
+func deflate(data []byte) []byte {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	zw.Write(data)
+	zw.Close()
+	return buf.Bytes()
+}
+
+func TestStream_Decode(t *testing.T) {
+	s := &Stream{Dict: Dictionary{"Filter": Name("FlateDecode")}, Raw: deflate([]byte("BT /F0 12 Tf ET"))}
+	data, err := s.Decode()
+	checkFatal(t, err == nil, "Decode should succeed.")
+	expectS(t, "BT /F0 12 Tf ET", string(data))
+
+	s = &Stream{Dict: Dictionary{"Filter": Array{Name("AHx"), Name("A85")}}, Raw: []byte("3C 7E 38 37 63 55 52 7E 3E>")}
+	data, err = s.Decode()
+	checkFatal(t, err == nil, "Decode should succeed.")
+	expectS(t, "Hell", string(data))
+
+	s = &Stream{Dict: Dictionary{}, Raw: []byte("q Q")}
+	data, _ = s.Decode()
+	expectS(t, "q Q", string(data))
+
+	s = &Stream{Dict: Dictionary{"Filter": Name("DCTDecode")}}
+	_, err = s.Decode()
+	check(t, err != nil, "Decode should fail for unsupported filter.")
+}
+
+func TestStream_Decode_predictor(t *testing.T) {
+	// Rows of 3 bytes using the None, Sub, Up, Average and Paeth filters.
+	rows := []byte{
+		0, 1, 2, 3,
+		1, 1, 1, 1,
+		2, 1, 1, 1,
+		3, 1, 1, 1,
+		4, 1, 1, 1,
+	}
+	s := &Stream{
+		Dict: Dictionary{
+			"Filter":      Name("FlateDecode"),
+			"DecodeParms": Dictionary{"Predictor": Integer(12), "Columns": Integer(3)},
+		},
+		Raw: deflate(rows),
+	}
+	data, err := s.Decode()
+	checkFatal(t, err == nil, "Decode should succeed.")
+	expectS(t, "[1 2 3 1 2 3 2 3 4 2 3 4 3 4 5]", fmt.Sprint(data))
+
+	s.Raw = deflate(rows[:7])
+	_, err = s.Decode()
+	check(t, err == errTruncatedPredictor, "Decode should fail for partial row.")
+}
@@ -0,0 +1,333 @@
+// Copyright 2015 Brent Rowland.
+// Use of this source code is governed the Apache License, Version 2.0, as described in the LICENSE file.
+
+package reader
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokInteger
+	tokReal
+	tokName
+	tokString
+	tokKeyword // Includes the delimiters <<, >>, [, ], { and }.
+)
+
+type token struct {
+	kind tokenKind
+	s    string
+	i    int
+	f    float64
+}
+
+func (tok token) is(kw string) bool {
+	return tok.kind == tokKeyword && tok.s == kw
+}
+
+// lexer splits PDF syntax into tokens, keeping track of its byte offset within the file.
+type lexer struct {
+	r   *bufio.Reader
+	pos int64
+}
+
+func newLexer(r io.Reader, pos int64) *lexer {
+	return &lexer{r: bufio.NewReader(r), pos: pos}
+}
+
+func isWhite(c byte) bool {
+	switch c {
+	case 0, '\t', '\n', '\f', '\r', ' ':
+		return true
+	}
+	return false
+}
+
+func isDelimiter(c byte) bool {
+	switch c {
+	case '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return false
+}
+
+func isRegular(c byte) bool {
+	return !isWhite(c) && !isDelimiter(c)
+}
+
+func unhex(c byte) (byte, bool) {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0', true
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10, true
+	case 'A' <= c && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
+
+func (lx *lexer) readByte() (byte, error) {
+	c, err := lx.r.ReadByte()
+	if err == nil {
+		lx.pos++
+	}
+	return c, err
+}
+
+func (lx *lexer) unreadByte() {
+	if lx.r.UnreadByte() == nil {
+		lx.pos--
+	}
+}
+
+func (lx *lexer) next() (tok token, err error) {
+	c, err := lx.skipWhite()
+	if err == io.EOF {
+		return token{kind: tokEOF}, nil
+	}
+	if err != nil {
+		return
+	}
+	switch c {
+	case '/':
+		return lx.name()
+	case '(':
+		return lx.literalString()
+	case '<':
+		if c, err = lx.readByte(); err != nil {
+			return tok, unexpectedEOF(err)
+		}
+		if c == '<' {
+			return token{kind: tokKeyword, s: "<<"}, nil
+		}
+		lx.unreadByte()
+		return lx.hexString()
+	case '>':
+		if c, err = lx.readByte(); err != nil {
+			return tok, unexpectedEOF(err)
+		}
+		if c == '>' {
+			return token{kind: tokKeyword, s: ">>"}, nil
+		}
+		return tok, fmt.Errorf("Unexpected > at offset %d.", lx.pos-1)
+	case '[', ']', '{', '}':
+		return token{kind: tokKeyword, s: string(c)}, nil
+	case ')':
+		return tok, fmt.Errorf("Unexpected ) at offset %d.", lx.pos-1)
+	}
+	lx.unreadByte()
+	word, err := lx.regular()
+	if err != nil {
+		return
+	}
+	return wordToken(word), nil
+}
+
+func wordToken(word []byte) token {
+	if len(word) > 0 && (word[0] == '+' || word[0] == '-' || word[0] == '.' || '0' <= word[0] && word[0] <= '9') {
+		s := string(word)
+		if bytes.IndexByte(word, '.') < 0 {
+			if i, err := strconv.Atoi(s); err == nil {
+				return token{kind: tokInteger, i: i}
+			}
+		}
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return token{kind: tokReal, f: f}
+		}
+	}
+	return token{kind: tokKeyword, s: string(word)}
+}
+
+// regular reads a run of regular characters.
+func (lx *lexer) regular() ([]byte, error) {
+	var word []byte
+	for {
+		c, err := lx.readByte()
+		if err == io.EOF {
+			return word, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !isRegular(c) {
+			lx.unreadByte()
+			return word, nil
+		}
+		word = append(word, c)
+	}
+}
+
+// skipWhite skips white space and comments, returning the first byte that follows.
+func (lx *lexer) skipWhite() (byte, error) {
+	for {
+		c, err := lx.readByte()
+		if err != nil {
+			return 0, err
+		}
+		if c == '%' {
+			for c != '\r' && c != '\n' {
+				if c, err = lx.readByte(); err != nil {
+					return 0, err
+				}
+			}
+		} else if !isWhite(c) {
+			return c, nil
+		}
+	}
+}
+
+// skipEOL skips the end-of-line marker that follows the stream keyword.
+func (lx *lexer) skipEOL() error {
+	c, err := lx.readByte()
+	if err != nil {
+		return unexpectedEOF(err)
+	}
+	if c == '\r' {
+		if c, err = lx.readByte(); err != nil {
+			return unexpectedEOF(err)
+		}
+	}
+	if c != '\n' {
+		lx.unreadByte()
+	}
+	return nil
+}
+
+func (lx *lexer) name() (tok token, err error) {
+	word, err := lx.regular()
+	if err != nil {
+		return
+	}
+	var n []byte
+	for i := 0; i < len(word); i++ {
+		if word[i] == '#' && i+2 < len(word) {
+			hi, ok1 := unhex(word[i+1])
+			lo, ok2 := unhex(word[i+2])
+			if ok1 && ok2 {
+				n = append(n, hi<<4|lo)
+				i += 2
+				continue
+			}
+		}
+		n = append(n, word[i])
+	}
+	return token{kind: tokName, s: string(n)}, nil
+}
+
+func (lx *lexer) literalString() (tok token, err error) {
+	var s []byte
+	depth := 1
+	for {
+		c, err := lx.readByte()
+		if err != nil {
+			return tok, unexpectedEOF(err)
+		}
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth == 0 {
+				return token{kind: tokString, s: string(s)}, nil
+			}
+		case '\r':
+			// An unescaped end-of-line marker of any kind is read as a line feed.
+			if c, err = lx.readByte(); err != nil {
+				return tok, unexpectedEOF(err)
+			}
+			if c != '\n' {
+				lx.unreadByte()
+			}
+			c = '\n'
+		case '\\':
+			if c, err = lx.readByte(); err != nil {
+				return tok, unexpectedEOF(err)
+			}
+			switch c {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r', '\n':
+				// A backslash at the end of a line continues the string on the next line.
+				if c == '\r' {
+					if c, err = lx.readByte(); err != nil {
+						return tok, unexpectedEOF(err)
+					}
+					if c != '\n' {
+						lx.unreadByte()
+					}
+				}
+				continue
+			case '0', '1', '2', '3', '4', '5', '6', '7':
+				n := c - '0'
+				for i := 0; i < 2; i++ {
+					if c, err = lx.readByte(); err != nil {
+						return tok, unexpectedEOF(err)
+					}
+					if c < '0' || c > '7' {
+						lx.unreadByte()
+						break
+					}
+					n = n<<3 | (c - '0')
+				}
+				c = n
+			}
+		}
+		s = append(s, c)
+	}
+}
+
+func (lx *lexer) hexString() (tok token, err error) {
+	var s []byte
+	var hi byte
+	odd := false
+	for {
+		c, err := lx.readByte()
+		if err != nil {
+			return tok, unexpectedEOF(err)
+		}
+		if c == '>' {
+			break
+		}
+		if isWhite(c) {
+			continue
+		}
+		d, ok := unhex(c)
+		if !ok {
+			return tok, fmt.Errorf("Invalid character %q in hexadecimal string at offset %d.", c, lx.pos-1)
+		}
+		if odd {
+			s = append(s, hi<<4|d)
+		} else {
+			hi = d
+		}
+		odd = !odd
+	}
+	// A final odd digit is followed by an implied zero.
+	if odd {
+		s = append(s, hi<<4)
+	}
+	return token{kind: tokString, s: string(s)}, nil
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
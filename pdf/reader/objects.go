@@ -0,0 +1,113 @@
+// Copyright 2015 Brent Rowland.
+// Use of this source code is governed the Apache License, Version 2.0, as described in the LICENSE file.
+
+// Package reader parses existing PDF files, whether written by package pdf or received from elsewhere.
+//
+// Objects are parsed into this package's own types, which mirror the unexported dictionary, array, name, str
+// and stream types package pdf writes, rather than into those types themselves. Package pdf imports this one
+// to import pages, so it cannot be imported here, and its types are built for writing: their elements must be
+// writers, and their references point to objects numbered for the document being written. Objects read from a file
+// refer to each other by the numbers in that file, so they must be renumbered as they are copied into a document
+// in any case, and pdf.DocWriter.ImportPage converts them to its own types as it does so.
+package reader
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Object is one of Array, Boolean, Dictionary, Integer, Name, Null, Real, Reference, String or *Stream.
+type Object interface{}
+
+type Array []Object
+
+type Boolean bool
+
+type Dictionary map[string]Object
+
+// Name returns the value of the name entry for key, or "" if there is none.
+func (d Dictionary) Name(key string) Name {
+	n, _ := d[key].(Name)
+	return n
+}
+
+// Keys returns the dictionary's keys in sorted order.
+func (d Dictionary) Keys() []string {
+	keys := make([]string, 0, len(d))
+	for key := range d {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+type Integer int
+
+type Name string
+
+type Null struct{}
+
+type Real float64
+
+// Rectangle holds the lower-left and upper-right corners of a rectangle in default user space.
+type Rectangle struct {
+	X1, Y1, X2, Y2 float64
+}
+
+func (r Rectangle) Height() float64 {
+	return r.Y2 - r.Y1
+}
+
+func (r Rectangle) Width() float64 {
+	return r.X2 - r.X1
+}
+
+// Reference identifies an indirect object by object number and generation.
+type Reference struct {
+	Num, Gen int
+}
+
+func (ref Reference) String() string {
+	return fmt.Sprintf("%d %d R", ref.Num, ref.Gen)
+}
+
+// Stream is a dictionary with data still encoded by the filters it names.
+// Length, Filter and DecodeParms are resolved when the stream is read.
+type Stream struct {
+	Dict Dictionary
+	Raw  []byte
+}
+
+// String holds the bytes of a literal or hexadecimal string.
+type String []byte
+
+func number(obj Object) (float64, bool) {
+	switch n := obj.(type) {
+	case Integer:
+		return float64(n), true
+	case Real:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func rectangle(obj Object) (Rectangle, bool) {
+	a, ok := obj.(Array)
+	if !ok || len(a) != 4 {
+		return Rectangle{}, false
+	}
+	var v [4]float64
+	for i, o := range a {
+		if v[i], ok = number(o); !ok {
+			return Rectangle{}, false
+		}
+	}
+	// Normalize so that X1, Y1 is the lower-left corner.
+	if v[0] > v[2] {
+		v[0], v[2] = v[2], v[0]
+	}
+	if v[1] > v[3] {
+		v[1], v[3] = v[3], v[1]
+	}
+	return Rectangle{v[0], v[1], v[2], v[3]}, true
+}
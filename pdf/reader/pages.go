@@ -0,0 +1,203 @@
+// Copyright 2015 Brent Rowland.
+// Use of this source code is governed the Apache License, Version 2.0, as described in the LICENSE file.
+
+package reader
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+var errPageTreeNode = errors.New("Page tree node is not a dictionary.")
+
+// Page is a leaf of the page tree, with the attributes it inherits from its ancestors resolved.
+type Page struct {
+	Dict      Dictionary
+	Resources Dictionary
+	MediaBox  Rectangle
+	CropBox   Rectangle
+	Rotate    int
+	r         *Reader
+}
+
+// inherited holds the page attributes that pass from a node of the page tree to its descendants.
+type inherited struct {
+	resources Object
+	mediaBox  Object
+	cropBox   Object
+	rotate    Object
+}
+
+func (inh inherited) update(node Dictionary) inherited {
+	if obj, ok := node["Resources"]; ok {
+		inh.resources = obj
+	}
+	if obj, ok := node["MediaBox"]; ok {
+		inh.mediaBox = obj
+	}
+	if obj, ok := node["CropBox"]; ok {
+		inh.cropBox = obj
+	}
+	if obj, ok := node["Rotate"]; ok {
+		inh.rotate = obj
+	}
+	return inh
+}
+
+// Pages returns the document's pages in order.
+func (r *Reader) Pages() ([]*Page, error) {
+	if r.pages != nil {
+		return r.pages, nil
+	}
+	catalog, err := r.Catalog()
+	if err != nil {
+		return nil, err
+	}
+	pages := []*Page{}
+	if err = r.walkPages(catalog["Pages"], inherited{}, make(map[int]bool), &pages); err != nil {
+		return nil, err
+	}
+	r.pages = pages
+	return pages, nil
+}
+
+func (r *Reader) walkPages(obj Object, inh inherited, visited map[int]bool, pages *[]*Page) error {
+	if ref, ok := obj.(Reference); ok {
+		if visited[ref.Num] {
+			return fmt.Errorf("Page tree visits object %d more than once.", ref.Num)
+		}
+		visited[ref.Num] = true
+	}
+	node, err := r.Dictionary(obj)
+	if err != nil {
+		return err
+	}
+	if node == nil {
+		return errPageTreeNode
+	}
+	inh = inh.update(node)
+	if _, ok := node["Kids"]; !ok || node.Name("Type") == "Page" {
+		page, err := r.newPage(node, inh)
+		if err != nil {
+			return err
+		}
+		*pages = append(*pages, page)
+		return nil
+	}
+	kids, err := r.Resolve(node["Kids"])
+	if err != nil {
+		return err
+	}
+	a, _ := kids.(Array)
+	for _, kid := range a {
+		if err := r.walkPages(kid, inh, visited, pages); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Reader) newPage(dict Dictionary, inh inherited) (*Page, error) {
+	page := &Page{Dict: dict, r: r}
+	var err error
+	if page.Resources, err = r.Dictionary(inh.resources); err != nil {
+		return nil, err
+	}
+	if page.Resources == nil {
+		page.Resources = Dictionary{}
+	}
+	mediaBox, err := r.Resolve(inh.mediaBox)
+	if err != nil {
+		return nil, err
+	}
+	page.MediaBox, _ = rectangle(mediaBox)
+	cropBox, err := r.Resolve(inh.cropBox)
+	if err != nil {
+		return nil, err
+	}
+	var ok bool
+	// The crop box defaults to the media box.
+	if page.CropBox, ok = rectangle(cropBox); !ok {
+		page.CropBox = page.MediaBox
+	}
+	rotate, err := r.Resolve(inh.rotate)
+	if err != nil {
+		return nil, err
+	}
+	if i, ok := rotate.(Integer); ok {
+		page.Rotate = int(i)
+	}
+	return page, nil
+}
+
+// Page returns the page numbered n, starting at 1.
+func (r *Reader) Page(n int) (*Page, error) {
+	pages, err := r.Pages()
+	if err != nil {
+		return nil, err
+	}
+	if n < 1 || n > len(pages) {
+		return nil, fmt.Errorf("Page %d out of range 1-%d.", n, len(pages))
+	}
+	return pages[n-1], nil
+}
+
+// Content returns the decoded data of the page's content streams, joined by line feeds.
+func (p *Page) Content() ([]byte, error) {
+	streams, err := p.ContentStreams()
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	for i, s := range streams {
+		data, err := s.Decode()
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			buf.WriteByte('\n')
+		}
+		buf.Write(data)
+	}
+	return buf.Bytes(), nil
+}
+
+// ContentStreams returns the page's content streams, which may be empty.
+func (p *Page) ContentStreams() ([]*Stream, error) {
+	contents, err := p.r.Resolve(p.Dict["Contents"])
+	if err != nil {
+		return nil, err
+	}
+	var objs Array
+	switch c := contents.(type) {
+	case *Stream:
+		return []*Stream{c}, nil
+	case Array:
+		objs = c
+	}
+	var streams []*Stream
+	for _, obj := range objs {
+		obj, err := p.r.Resolve(obj)
+		if err != nil {
+			return nil, err
+		}
+		if s, ok := obj.(*Stream); ok {
+			streams = append(streams, s)
+		}
+	}
+	return streams, nil
+}
+
+// Resource returns the named resource of the given category, such as Font or XObject, or nil if there is none.
+func (p *Page) Resource(category, name string) (Object, error) {
+	resources, err := p.r.Dictionary(p.Resources[category])
+	if err != nil || resources == nil {
+		return nil, err
+	}
+	obj, ok := resources[name]
+	if !ok {
+		return nil, nil
+	}
+	return p.r.Resolve(obj)
+}
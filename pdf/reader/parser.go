@@ -0,0 +1,179 @@
+// Copyright 2015 Brent Rowland.
+// Use of this source code is governed the Apache License, Version 2.0, as described in the LICENSE file.
+
+package reader
+
+import (
+	"fmt"
+	"io"
+)
+
+// parser builds objects from the tokens of a lexer, looking ahead as needed to recognize references.
+type parser struct {
+	lx   *lexer
+	back []token
+}
+
+func newParser(r io.Reader, pos int64) *parser {
+	return &parser{lx: newLexer(r, pos)}
+}
+
+func (p *parser) next() (token, error) {
+	if n := len(p.back); n > 0 {
+		tok := p.back[n-1]
+		p.back = p.back[:n-1]
+		return tok, nil
+	}
+	return p.lx.next()
+}
+
+func (p *parser) unread(tok token) {
+	p.back = append(p.back, tok)
+}
+
+func (p *parser) expectInteger() (int, error) {
+	tok, err := p.next()
+	if err != nil {
+		return 0, err
+	}
+	if tok.kind != tokInteger {
+		return 0, fmt.Errorf("Expected integer at offset %d.", p.lx.pos)
+	}
+	return tok.i, nil
+}
+
+func (p *parser) expectKeyword(kw string) error {
+	tok, err := p.next()
+	if err != nil {
+		return err
+	}
+	if !tok.is(kw) {
+		return fmt.Errorf("Expected %s at offset %d.", kw, p.lx.pos)
+	}
+	return nil
+}
+
+// parseObject parses a direct object, or a reference to an indirect one.
+func (p *parser) parseObject() (Object, error) {
+	tok, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	switch tok.kind {
+	case tokEOF:
+		return nil, io.ErrUnexpectedEOF
+	case tokInteger:
+		return p.integerOrReference(tok)
+	case tokReal:
+		return Real(tok.f), nil
+	case tokName:
+		return Name(tok.s), nil
+	case tokString:
+		return String(tok.s), nil
+	}
+	switch tok.s {
+	case "<<":
+		return p.parseDictionary()
+	case "[":
+		return p.parseArray()
+	case "true":
+		return Boolean(true), nil
+	case "false":
+		return Boolean(false), nil
+	case "null":
+		return Null{}, nil
+	}
+	return nil, fmt.Errorf("Unexpected %s at offset %d.", tok.s, p.lx.pos)
+}
+
+func (p *parser) integerOrReference(tok token) (Object, error) {
+	tok2, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	if tok2.kind != tokInteger {
+		p.unread(tok2)
+		return Integer(tok.i), nil
+	}
+	tok3, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	if tok3.is("R") {
+		return Reference{tok.i, tok2.i}, nil
+	}
+	p.unread(tok3)
+	p.unread(tok2)
+	return Integer(tok.i), nil
+}
+
+func (p *parser) parseArray() (Object, error) {
+	a := Array{}
+	for {
+		tok, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		if tok.is("]") {
+			return a, nil
+		}
+		p.unread(tok)
+		obj, err := p.parseObject()
+		if err != nil {
+			return nil, err
+		}
+		a = append(a, obj)
+	}
+}
+
+func (p *parser) parseDictionary() (Object, error) {
+	d := Dictionary{}
+	for {
+		tok, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		if tok.is(">>") {
+			return d, nil
+		}
+		if tok.kind != tokName {
+			return nil, fmt.Errorf("Expected name as dictionary key at offset %d.", p.lx.pos)
+		}
+		value, err := p.parseObject()
+		if err != nil {
+			return nil, err
+		}
+		// A null value is equivalent to an absent entry.
+		if _, ok := value.(Null); !ok {
+			d[tok.s] = value
+		}
+	}
+}
+
+// parseIndirectObject parses "num gen obj", the object and whatever keyword follows it.
+// For a stream, the keyword is "stream" and the lexer is left at the start of the stream's data.
+func (p *parser) parseIndirectObject() (num, gen int, obj Object, kw token, err error) {
+	if num, err = p.expectInteger(); err != nil {
+		return
+	}
+	if gen, err = p.expectInteger(); err != nil {
+		return
+	}
+	if err = p.expectKeyword("obj"); err != nil {
+		return
+	}
+	if obj, err = p.parseObject(); err != nil {
+		return
+	}
+	if kw, err = p.next(); err != nil {
+		return
+	}
+	if kw.is("stream") {
+		if len(p.back) > 0 {
+			err = fmt.Errorf("Unexpected stream at offset %d.", p.lx.pos)
+			return
+		}
+		err = p.lx.skipEOL()
+	}
+	return
+}
@@ -0,0 +1,81 @@
+// Copyright 2015 Brent Rowland.
+// Use of this source code is governed the Apache License, Version 2.0, as described in the LICENSE file.
+
+package reader
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func parse(s string) (Object, error) {
+	return newParser(strings.NewReader(s), 0).parseObject()
+}
+
+func TestParser_parseObject(t *testing.T) {
+	tests := []struct {
+		s        string
+		expected Object
+	}{
+		{"42", Integer(42)},
+		{"-3.5", Real(-3.5)},
+		{".5", Real(0.5)},
+		{"true", Boolean(true)},
+		{"null", Null{}},
+		{"/Name#20With#23Escapes", Name("Name With#Escapes")},
+		{"(a (nested) string\\051\\n)", String("a (nested) string)\n")},
+		{"(line\\\ncontinued\r\nand\\0)", String("linecontinued\nand\x00")},
+		{"<48 65 6C6C 6f7>", String("Hellop")},
+		{"12 0 R", Reference{12, 0}},
+		{"[1 2 0 R 3 /A]", Array{Integer(1), Reference{2, 0}, Integer(3), Name("A")}},
+	}
+	for _, test := range tests {
+		obj, err := parse(test.s)
+		if err != nil {
+			t.Errorf("%s: %v", test.s, err)
+			continue
+		}
+		check(t, reflect.DeepEqual(test.expected, obj), fmt.Sprintf("Expected %#v, got %#v", test.expected, obj))
+	}
+}
+
+func TestParser_parseDictionary(t *testing.T) {
+	obj, err := parse("<< /Type /Page % comment\n /Kids [] /Parent 3 0 R /Gone null /Sub << /A (x) >> >>")
+	checkFatal(t, err == nil, "parseObject should succeed.")
+	d, ok := obj.(Dictionary)
+	checkFatal(t, ok, "Object should be a dictionary.")
+	expectS(t, "Kids Parent Sub Type", strings.Join(d.Keys(), " "))
+	expectS(t, "Page", string(d.Name("Type")))
+	check(t, d["Parent"] == Reference{3, 0}, "Parent should be a reference.")
+	sub, _ := d["Sub"].(Dictionary)
+	expectS(t, "x", string(sub["A"].(String)))
+}
+
+func TestParser_parseObject_errors(t *testing.T) {
+	for _, s := range []string{"", "(unterminated", "<< /A >>", "<< 1 2 >>", "[1 2", "<4G>", ")", "endobj"} {
+		_, err := parse(s)
+		check(t, err != nil, fmt.Sprintf("Parsing %q should fail.", s))
+	}
+}
+
+func TestParser_parseIndirectObject(t *testing.T) {
+	p := newParser(strings.NewReader("7 0 obj\n<< /Length 5 >>\nstream\r\nHello\nendstream\nendobj\n"), 100)
+	num, gen, obj, kw, err := p.parseIndirectObject()
+	checkFatal(t, err == nil, "parseIndirectObject should succeed.")
+	expectI(t, 7, num)
+	expectI(t, 0, gen)
+	check(t, kw.is("stream"), "Object should be followed by stream.")
+	expectI(t, 132, int(p.lx.pos))
+	_, ok := obj.(Dictionary)
+	check(t, ok, "Stream should have a dictionary.")
+
+	p = newParser(strings.NewReader("8 1 obj 42 endobj"), 0)
+	num, gen, obj, kw, err = p.parseIndirectObject()
+	checkFatal(t, err == nil, "parseIndirectObject should succeed.")
+	expectI(t, 8, num)
+	expectI(t, 1, gen)
+	check(t, obj == Integer(42), "Object should be 42.")
+	check(t, kw.is("endobj"), "Object should be followed by endobj.")
+}
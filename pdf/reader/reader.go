@@ -0,0 +1,492 @@
+// Copyright 2015 Brent Rowland.
+// Use of this source code is governed the Apache License, Version 2.0, as described in the LICENSE file.
+
+package reader
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+)
+
+var (
+	errEncrypted   = errors.New("Encrypted documents are not supported.")
+	errNoHeader    = errors.New("Document has no PDF header.")
+	errNoStartXRef = errors.New("Document has no startxref.")
+	errNoCatalog   = errors.New("Document has no catalog.")
+	errXRefLoop    = errors.New("Cross-reference sections form a loop.")
+)
+
+// Reader gives access to the objects of a PDF file, reading them as they are needed.
+type Reader struct {
+	rs      io.ReadSeeker
+	size    int64
+	version string
+	xref    xRefEntries
+	objects map[int]Object
+	objStms map[int]*objectStream
+	loading map[int]bool
+	pages   []*Page
+	Trailer Dictionary
+}
+
+type xRefEntry struct {
+	kind   int   // 0 for free, 1 for in use, 2 for compressed objects.
+	offset int64 // Byte offset of an object in use.
+	gen    int
+	objStm int // Object stream containing a compressed object.
+	index  int // Index of a compressed object within its object stream.
+}
+
+type objectStream struct {
+	data    []byte
+	offsets []int
+}
+
+var (
+	headerPattern    = regexp.MustCompile(`%PDF-(\d\.\d)`)
+	startXRefPattern = regexp.MustCompile(`startxref\s+(\d+)`)
+)
+
+// New reads the header, cross-reference sections and trailer of the PDF file read by rs.
+// Indirect objects are read from rs as they are needed, so it must remain open while the Reader is in use.
+func New(rs io.ReadSeeker) (*Reader, error) {
+	size, err := rs.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	r := &Reader{
+		rs:      rs,
+		size:    size,
+		xref:    make(xRefEntries),
+		objects: make(map[int]Object),
+		objStms: make(map[int]*objectStream),
+		loading: make(map[int]bool),
+	}
+	head, err := r.readAt(0, 1024)
+	if err != nil {
+		return nil, err
+	}
+	m := headerPattern.FindSubmatch(head)
+	if m == nil {
+		return nil, errNoHeader
+	}
+	r.version = string(m[1])
+	tail, err := r.readAt(size-1024, 1024)
+	if err != nil {
+		return nil, err
+	}
+	ms := startXRefPattern.FindAllSubmatch(tail, -1)
+	if ms == nil {
+		return nil, errNoStartXRef
+	}
+	offset, _ := strconv.ParseInt(string(ms[len(ms)-1][1]), 10, 64)
+	if err = r.readXRef(offset); err != nil {
+		return nil, err
+	}
+	if _, ok := r.Trailer["Encrypt"]; ok {
+		return nil, errEncrypted
+	}
+	return r, nil
+}
+
+// readAt reads up to n bytes at offset, clipped to the bounds of the file.
+func (r *Reader) readAt(offset, n int64) ([]byte, error) {
+	if offset < 0 {
+		n += offset
+		offset = 0
+	}
+	if offset+n > r.size {
+		n = r.size - offset
+	}
+	if _, err := r.rs.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, n)
+	_, err := io.ReadFull(r.rs, buf)
+	return buf, err
+}
+
+func (r *Reader) parserAt(offset int64) (*parser, error) {
+	if _, err := r.rs.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return newParser(r.rs, offset), nil
+}
+
+// readXRef reads the chain of cross-reference sections starting at offset.
+// Entries from later sections, which are read first, take precedence over those they update.
+func (r *Reader) readXRef(offset int64) error {
+	seen := make(map[int64]bool)
+	for {
+		if seen[offset] {
+			return errXRefLoop
+		}
+		seen[offset] = true
+		entries := make(xRefEntries)
+		trailer, err := r.readXRefSection(offset, entries)
+		if err != nil {
+			return err
+		}
+		if r.Trailer == nil {
+			r.Trailer = trailer
+		}
+		// Hybrid files list compressed objects in a stream that supplements the table.
+		if stm, ok := trailer["XRefStm"].(Integer); ok && !seen[int64(stm)] {
+			seen[int64(stm)] = true
+			stmEntries := make(xRefEntries)
+			if _, err := r.readXRefSection(int64(stm), stmEntries); err != nil {
+				return err
+			}
+			// The table may list the compressed objects as free, for readers unaware of the stream.
+			for num, e := range stmEntries {
+				if old, ok := entries[num]; !ok || old.kind == 0 {
+					entries[num] = e
+				}
+			}
+		}
+		for num, e := range entries {
+			r.xref.set(num, e)
+		}
+		prev, ok := trailer["Prev"].(Integer)
+		if !ok {
+			return nil
+		}
+		offset = int64(prev)
+	}
+}
+
+// xRefEntries holds cross-reference entries by object number.
+type xRefEntries map[int]xRefEntry
+
+// set adds e for object num, unless an entry has already been read for it.
+func (entries xRefEntries) set(num int, e xRefEntry) {
+	if _, ok := entries[num]; !ok {
+		entries[num] = e
+	}
+}
+
+// readXRefSection reads a cross-reference table or stream at offset into entries, returning its trailer dictionary.
+func (r *Reader) readXRefSection(offset int64, entries xRefEntries) (Dictionary, error) {
+	p, err := r.parserAt(offset)
+	if err != nil {
+		return nil, err
+	}
+	tok, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	if !tok.is("xref") {
+		return r.readXRefStream(offset, entries)
+	}
+	for {
+		tok, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		if tok.is("trailer") {
+			break
+		}
+		p.unread(tok)
+		first, err := p.expectInteger()
+		if err != nil {
+			return nil, err
+		}
+		count, err := p.expectInteger()
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < count; i++ {
+			offset, err := p.expectInteger()
+			if err != nil {
+				return nil, err
+			}
+			gen, err := p.expectInteger()
+			if err != nil {
+				return nil, err
+			}
+			tok, err := p.next()
+			if err != nil {
+				return nil, err
+			}
+			switch {
+			case tok.is("n"):
+				entries.set(first+i, xRefEntry{kind: 1, offset: int64(offset), gen: gen})
+			case tok.is("f"):
+				entries.set(first+i, xRefEntry{kind: 0, gen: gen})
+			default:
+				return nil, fmt.Errorf("Invalid cross-reference entry for object %d.", first+i)
+			}
+		}
+	}
+	obj, err := p.parseObject()
+	if err != nil {
+		return nil, err
+	}
+	trailer, ok := obj.(Dictionary)
+	if !ok {
+		return nil, fmt.Errorf("Invalid trailer at offset %d.", offset)
+	}
+	return trailer, nil
+}
+
+func (r *Reader) readXRefStream(offset int64, entries xRefEntries) (Dictionary, error) {
+	_, obj, err := r.readObjectAt(offset)
+	if err != nil {
+		return nil, err
+	}
+	s, ok := obj.(*Stream)
+	if !ok || s.Dict.Name("Type") != "XRef" {
+		return nil, fmt.Errorf("No cross-reference section at offset %d.", offset)
+	}
+	data, err := s.Decode()
+	if err != nil {
+		return nil, err
+	}
+	w, _ := s.Dict["W"].(Array)
+	if len(w) != 3 {
+		return nil, fmt.Errorf("Invalid cross-reference stream at offset %d.", offset)
+	}
+	var widths [3]int
+	rowLen := 0
+	for i, obj := range w {
+		n, _ := obj.(Integer)
+		if n < 0 || n > 8 {
+			return nil, fmt.Errorf("Invalid cross-reference stream at offset %d.", offset)
+		}
+		widths[i] = int(n)
+		rowLen += int(n)
+	}
+	index, _ := s.Dict["Index"].(Array)
+	if index == nil {
+		index = Array{Integer(0), s.Dict["Size"]}
+	}
+	for i := 0; i+1 < len(index); i += 2 {
+		first, _ := index[i].(Integer)
+		count, _ := index[i+1].(Integer)
+		for j := 0; j < int(count); j++ {
+			if len(data) < rowLen {
+				return nil, fmt.Errorf("Cross-reference stream at offset %d is too short.", offset)
+			}
+			var fields [3]int64
+			for k, width := range widths {
+				for _, b := range data[:width] {
+					fields[k] = fields[k]<<8 | int64(b)
+				}
+				data = data[width:]
+			}
+			// The type field defaults to 1 when omitted.
+			if widths[0] == 0 {
+				fields[0] = 1
+			}
+			num := int(first) + j
+			switch fields[0] {
+			case 0:
+				entries.set(num, xRefEntry{kind: 0, gen: int(fields[2])})
+			case 1:
+				entries.set(num, xRefEntry{kind: 1, offset: fields[1], gen: int(fields[2])})
+			case 2:
+				entries.set(num, xRefEntry{kind: 2, objStm: int(fields[1]), index: int(fields[2])})
+			}
+		}
+	}
+	return s.Dict, nil
+}
+
+// readObjectAt reads the indirect object at offset, along with a stream's data if it has any.
+func (r *Reader) readObjectAt(offset int64) (num int, obj Object, err error) {
+	p, err := r.parserAt(offset)
+	if err != nil {
+		return
+	}
+	num, _, obj, kw, err := p.parseIndirectObject()
+	if err != nil || !kw.is("stream") {
+		return
+	}
+	dict, ok := obj.(Dictionary)
+	if !ok {
+		return num, nil, fmt.Errorf("Stream for object %d lacks dictionary.", num)
+	}
+	start := p.lx.pos
+	s := &Stream{Dict: dict}
+	for _, key := range []string{"Length", "Filter", "DecodeParms"} {
+		if ref, ok := dict[key].(Reference); ok {
+			if dict[key], err = r.Object(ref); err != nil {
+				return
+			}
+		}
+	}
+	if length, ok := dict["Length"].(Integer); ok && length >= 0 && start+int64(length) <= r.size {
+		s.Raw, err = r.readAt(start, int64(length))
+	} else {
+		s.Raw, err = r.readToEndStream(start)
+	}
+	return num, s, err
+}
+
+var endStream = []byte("endstream")
+
+// readToEndStream reads stream data of unknown length, up to the endstream keyword.
+func (r *Reader) readToEndStream(start int64) ([]byte, error) {
+	if _, err := r.rs.Seek(start, io.SeekStart); err != nil {
+		return nil, err
+	}
+	var data []byte
+	br := bufio.NewReader(r.rs)
+	for {
+		line, err := br.ReadBytes('\n')
+		data = append(data, line...)
+		if i := bytes.Index(data, endStream); i >= 0 {
+			data = data[:i]
+			// Drop the end-of-line marker that precedes endstream.
+			data = bytes.TrimSuffix(data, []byte("\n"))
+			data = bytes.TrimSuffix(data, []byte("\r"))
+			return data, nil
+		}
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+	}
+}
+
+// Object returns the indirect object referred to by ref, or Null if it does not exist.
+func (r *Reader) Object(ref Reference) (Object, error) {
+	if obj, ok := r.objects[ref.Num]; ok {
+		return obj, nil
+	}
+	e, ok := r.xref[ref.Num]
+	if !ok || e.kind == 0 {
+		return Null{}, nil
+	}
+	if r.loading[ref.Num] {
+		return nil, fmt.Errorf("Object %d refers to itself.", ref.Num)
+	}
+	r.loading[ref.Num] = true
+	defer delete(r.loading, ref.Num)
+	var obj Object
+	var err error
+	if e.kind == 1 {
+		var num int
+		if num, obj, err = r.readObjectAt(e.offset); err == nil && num != ref.Num {
+			err = fmt.Errorf("Object %d not found at offset %d.", ref.Num, e.offset)
+		}
+	} else {
+		obj, err = r.compressedObject(ref.Num, e)
+	}
+	if err != nil {
+		return nil, err
+	}
+	r.objects[ref.Num] = obj
+	return obj, nil
+}
+
+func (r *Reader) compressedObject(num int, e xRefEntry) (Object, error) {
+	stm, err := r.objectStream(e.objStm)
+	if err != nil {
+		return nil, err
+	}
+	if e.index >= len(stm.offsets) {
+		return nil, fmt.Errorf("Object %d not found in object stream %d.", num, e.objStm)
+	}
+	end := len(stm.data)
+	if e.index+1 < len(stm.offsets) {
+		end = stm.offsets[e.index+1]
+	}
+	start := stm.offsets[e.index]
+	if start > end || end > len(stm.data) {
+		return nil, fmt.Errorf("Invalid offset for object %d in object stream %d.", num, e.objStm)
+	}
+	return newParser(bytes.NewReader(stm.data[start:end]), 0).parseObject()
+}
+
+func (r *Reader) objectStream(num int) (*objectStream, error) {
+	if stm, ok := r.objStms[num]; ok {
+		return stm, nil
+	}
+	obj, err := r.Object(Reference{num, 0})
+	if err != nil {
+		return nil, err
+	}
+	s, ok := obj.(*Stream)
+	if !ok || s.Dict.Name("Type") != "ObjStm" {
+		return nil, fmt.Errorf("Object %d is not an object stream.", num)
+	}
+	data, err := s.Decode()
+	if err != nil {
+		return nil, err
+	}
+	n, _ := s.Dict["N"].(Integer)
+	first, _ := s.Dict["First"].(Integer)
+	if n < 0 || first < 0 || int(first) > len(data) {
+		return nil, fmt.Errorf("Invalid object stream %d.", num)
+	}
+	p := newParser(bytes.NewReader(data[:first]), 0)
+	stm := &objectStream{data: data, offsets: make([]int, n)}
+	for i := range stm.offsets {
+		if _, err = p.expectInteger(); err != nil {
+			return nil, err
+		}
+		offset, err := p.expectInteger()
+		if err != nil {
+			return nil, err
+		}
+		stm.offsets[i] = int(first) + offset
+	}
+	r.objStms[num] = stm
+	return stm, nil
+}
+
+// Resolve follows references until it reaches a direct object or a stream.
+func (r *Reader) Resolve(obj Object) (Object, error) {
+	for i := 0; ; i++ {
+		ref, ok := obj.(Reference)
+		if !ok {
+			return obj, nil
+		}
+		if i > len(r.xref) {
+			return nil, fmt.Errorf("Object %d refers to itself.", ref.Num)
+		}
+		var err error
+		if obj, err = r.Object(ref); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Dictionary resolves obj, returning nil if it is not a dictionary.
+func (r *Reader) Dictionary(obj Object) (Dictionary, error) {
+	obj, err := r.Resolve(obj)
+	if err != nil {
+		return nil, err
+	}
+	d, _ := obj.(Dictionary)
+	return d, nil
+}
+
+// Catalog returns the document catalog, the root of its object hierarchy.
+func (r *Reader) Catalog() (Dictionary, error) {
+	catalog, err := r.Dictionary(r.Trailer["Root"])
+	if err == nil && catalog == nil {
+		err = errNoCatalog
+	}
+	return catalog, err
+}
+
+// Info returns the document information dictionary, or nil if there is none.
+func (r *Reader) Info() (Dictionary, error) {
+	return r.Dictionary(r.Trailer["Info"])
+}
+
+// Version returns the PDF version from the file header, or from the catalog if it has been raised there.
+func (r *Reader) Version() string {
+	if catalog, err := r.Catalog(); err == nil {
+		if v := string(catalog.Name("Version")); v > r.version {
+			return v
+		}
+	}
+	return r.version
+}
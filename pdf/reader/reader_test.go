@@ -0,0 +1,249 @@
+// Copyright 2015 Brent Rowland.
+// Use of this source code is governed the Apache License, Version 2.0, as described in the LICENSE file.
+
+package reader_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/rowland/leadtype/afm_fonts"
+	"github.com/rowland/leadtype/options"
+	"github.com/rowland/leadtype/pdf"
+	"github.com/rowland/leadtype/pdf/reader"
+)
+
+// sampleDoc writes a three-page document with text, a bookmark and a link, configured by setup.
+func sampleDoc(t *testing.T, setup func(dw *pdf.DocWriter)) *bytes.Reader {
+	fc, err := afm_fonts.New("../../afm/data/fonts/*.afm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dw := pdf.NewDocWriter()
+	dw.AddFontSource(fc)
+	dw.SetInfo(pdf.Info{Title: "Sample"})
+	setup(dw)
+	for i := 0; i < 3; i++ {
+		dw.NewPage()
+		if _, err = dw.SetFont("Helvetica", 12, options.Options{}); err != nil {
+			t.Fatal(err)
+		}
+		dw.MoveTo(72, 72)
+		dw.Print("Page")
+	}
+	dw.AddBookmark("Last", 3, 0, nil)
+	dw.AddLink(72, 72, 72, 12, "http://example.com")
+	var buf bytes.Buffer
+	if _, err = dw.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return bytes.NewReader(buf.Bytes())
+}
+
+func checkSampleDoc(t *testing.T, r *reader.Reader, version string) {
+	if v := r.Version(); v != version {
+		t.Errorf("Expected version %s, got %s", version, v)
+	}
+	info, err := r.Info()
+	if err != nil || info == nil {
+		t.Fatalf("Info should be readable: %v", err)
+	}
+	if title, _ := info["Title"].(reader.String); string(title) != "Sample" {
+		t.Errorf("Expected title Sample, got %s", title)
+	}
+	catalog, err := r.Catalog()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if catalog.Name("PageMode") != "UseOutlines" {
+		t.Error("Catalog should show outlines.")
+	}
+	pages, err := r.Pages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pages) != 3 {
+		t.Fatalf("Expected 3 pages, got %d", len(pages))
+	}
+	for _, page := range pages {
+		if page.MediaBox != (reader.Rectangle{0, 0, 612, 792}) {
+			t.Errorf("Unexpected media box %v", page.MediaBox)
+		}
+		if page.CropBox != page.MediaBox {
+			t.Errorf("Crop box should default to media box, got %v", page.CropBox)
+		}
+		content, err := page.Content()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(content), "(Page) Tj") {
+			t.Errorf("Content should show text: %s", content)
+		}
+		f, err := page.Resource("Font", "F0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if font, _ := f.(reader.Dictionary); font.Name("BaseFont") != "Helvetica" {
+			t.Errorf("Expected font resource F0 to be Helvetica, got %v", f)
+		}
+	}
+	annots, err := r.Resolve(pages[2].Dict["Annots"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a, _ := annots.(reader.Array); len(a) != 1 {
+		t.Errorf("Last page should have one annotation, got %v", annots)
+	}
+	if _, err = r.Page(4); err == nil {
+		t.Error("Page 4 should be out of range.")
+	}
+}
+
+func TestReader_xrefTable(t *testing.T) {
+	r, err := reader.New(sampleDoc(t, func(dw *pdf.DocWriter) {}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSampleDoc(t, r, "1.3")
+	if size, _ := r.Trailer["Size"].(reader.Integer); size < 10 {
+		t.Errorf("Unexpected trailer size %d", size)
+	}
+}
+
+func TestReader_uncompressed(t *testing.T) {
+	r, err := reader.New(sampleDoc(t, func(dw *pdf.DocWriter) {
+		dw.SetCompressionLevel(pdf.NoCompression)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSampleDoc(t, r, "1.3")
+}
+
+func TestReader_objectStreams(t *testing.T) {
+	r, err := reader.New(sampleDoc(t, func(dw *pdf.DocWriter) {
+		dw.SetObjectStreams(true)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSampleDoc(t, r, "1.5")
+	if r.Trailer.Name("Type") != "XRef" {
+		t.Error("Trailer should come from cross-reference stream.")
+	}
+}
+
+// hybridDoc builds a hybrid file, whose table lists the objects compressed in object stream 4 as free
+// and refers to a cross-reference stream listing them. If update is set, an update frees object 6.
+func hybridDoc(update bool) *bytes.Reader {
+	var buf bytes.Buffer
+	offsets := make(map[int]int)
+	obj := func(num int, s string) {
+		offsets[num] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", num, s)
+	}
+	stream := func(num int, dict, data string) {
+		obj(num, fmt.Sprintf("<< %s /Length %d >>\nstream\n%s\nendstream", dict, len(data), data))
+	}
+	buf.WriteString("%PDF-1.5\n")
+	obj(1, "<< /Type /Catalog /Pages 2 0 R >>")
+	obj(2, "<< /Type /Pages /Kids [3 0 R] /Count 1 >>")
+	page := "<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] >>"
+	header := fmt.Sprintf("3 0 6 %d ", len(page)+1)
+	stream(4, fmt.Sprintf("/Type /ObjStm /N 2 /First %d", len(header)), header+page+" 42")
+	stream(5, "/Type /XRef /Size 7 /W [1 2 1] /Index [3 1 6 1]", "\x02\x00\x04\x00\x02\x00\x04\x01")
+	xref := buf.Len()
+	buf.WriteString("xref\n0 7\n0000000000 65535 f\r\n")
+	for num := 1; num <= 6; num++ {
+		if offset, ok := offsets[num]; ok {
+			fmt.Fprintf(&buf, "%010d 00000 n\r\n", offset)
+		} else {
+			buf.WriteString("0000000000 00000 f\r\n")
+		}
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size 7 /Root 1 0 R /XRefStm %d >>\nstartxref\n%d\n%%%%EOF\n", offsets[5], xref)
+	if update {
+		prev := xref
+		xref = buf.Len()
+		buf.WriteString("xref\n0 1\n0000000000 65535 f\r\n6 1\n0000000000 00001 f\r\n")
+		fmt.Fprintf(&buf, "trailer\n<< /Size 7 /Root 1 0 R /Prev %d >>\nstartxref\n%d\n%%%%EOF\n", prev, xref)
+	}
+	return bytes.NewReader(buf.Bytes())
+}
+
+func TestReader_hybrid(t *testing.T) {
+	r, err := reader.New(hybridDoc(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pages, err := r.Pages()
+	if err != nil || len(pages) != 1 {
+		t.Fatalf("Expected 1 page, got %d: %v", len(pages), err)
+	}
+	if pages[0].MediaBox.Width() != 612 {
+		t.Errorf("Expected page width 612, got %f", pages[0].MediaBox.Width())
+	}
+	if obj, err := r.Object(reader.Reference{Num: 6}); obj != reader.Integer(42) {
+		t.Errorf("Expected compressed object 6 to be 42, got %v: %v", obj, err)
+	}
+
+	r, err = reader.New(hybridDoc(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pages, err := r.Pages(); err != nil || len(pages) != 1 {
+		t.Errorf("Expected 1 page after update, got %d: %v", len(pages), err)
+	}
+	if obj, _ := r.Object(reader.Reference{Num: 6}); obj != (reader.Null{}) {
+		t.Errorf("Object 6 freed by update should be null, got %v", obj)
+	}
+}
+
+func TestReader_streaming(t *testing.T) {
+	fc, err := afm_fonts.New("../../afm/data/fonts/*.afm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	dw := pdf.NewStreamingDocWriter(&buf)
+	dw.AddFontSource(fc)
+	for i := 0; i < 2; i++ {
+		dw.NewPage()
+		dw.SetFont("Helvetica", 12, options.Options{})
+		dw.Print("Streamed")
+	}
+	if err = dw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	r, err := reader.New(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	page, err := r.Page(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := page.Content(); !strings.Contains(string(content), "(Streamed) Tj") {
+		t.Errorf("Content should show text: %s", content)
+	}
+}
+
+func TestReader_encrypted(t *testing.T) {
+	dw := pdf.NewDocWriter()
+	dw.SetEncryption(pdf.AES_128, "", "secret", pdf.PermitAll)
+	var buf bytes.Buffer
+	dw.WriteTo(&buf)
+	if _, err := reader.New(bytes.NewReader(buf.Bytes())); err == nil {
+		t.Error("Encrypted document should be rejected.")
+	}
+}
+
+func TestReader_invalid(t *testing.T) {
+	for _, s := range []string{"", "Not a PDF", "%PDF-1.4\nno cross-reference\n", "%PDF-1.4\nstartxref\n9\n%%EOF\n"} {
+		if _, err := reader.New(strings.NewReader(s)); err == nil {
+			t.Errorf("Reading %q should fail.", s)
+		}
+	}
+}
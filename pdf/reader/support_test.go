@@ -0,0 +1,32 @@
+// Copyright 2015 Brent Rowland.
+// Use of this source code is governed the Apache License, Version 2.0, as described in the LICENSE file.
+
+package reader
+
+import (
+	"testing"
+)
+
+func check(t *testing.T, condition bool, msg string) {
+	if !condition {
+		t.Error(msg)
+	}
+}
+
+func checkFatal(t *testing.T, condition bool, msg string) {
+	if !condition {
+		t.Fatal(msg)
+	}
+}
+
+func expectI(t *testing.T, expected, actual int) {
+	if expected != actual {
+		t.Errorf("Expected %d, got %d", expected, actual)
+	}
+}
+
+func expectS(t *testing.T, expected, actual string) {
+	if expected != actual {
+		t.Errorf("Expected |%s|, got |%s|", expected, actual)
+	}
+}
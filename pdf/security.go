@@ -0,0 +1,381 @@
+// Copyright 2015 Brent Rowland.
+// Use of this source code is governed the Apache License, Version 2.0, as described in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/rc4"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"hash"
+	"io"
+)
+
+// Encryption selects the algorithm of the standard security handler.
+type Encryption int
+
+const (
+	NoEncryption Encryption = iota
+	RC4_128                 // RC4 with a 128-bit key (revision 3), readable by Acrobat 5 and later
+	AES_128                 // AES with a 128-bit key (revision 4), readable by Acrobat 7 and later
+	AES_256                 // AES with a 256-bit key (revision 6), readable by Acrobat X and later
+)
+
+// Permissions are the operations allowed to users opening a document with the user password.
+type Permissions uint32
+
+const (
+	PermitPrint            Permissions = 1 << 2
+	PermitModify           Permissions = 1 << 3
+	PermitCopy             Permissions = 1 << 4
+	PermitAnnotate         Permissions = 1 << 5
+	PermitFillForms        Permissions = 1 << 8
+	PermitAccessibility    Permissions = 1 << 9
+	PermitAssemble         Permissions = 1 << 10
+	PermitPrintHighQuality Permissions = 1 << 11
+	PermitAll                          = PermitPrint | PermitModify | PermitCopy | PermitAnnotate |
+		PermitFillForms | PermitAccessibility | PermitAssemble | PermitPrintHighQuality
+)
+
+var (
+	errEncryptionTooLate = errors.New("Encryption must be set before the first page is written.")
+	errUnknownEncryption = errors.New("Unknown encryption method.")
+)
+
+var passwordPadding = []byte{
+	0x28, 0xBF, 0x4E, 0x5E, 0x4E, 0x75, 0x8A, 0x41, 0x64, 0x00, 0x4E, 0x56, 0xFF, 0xFA, 0x01, 0x08,
+	0x2E, 0x2E, 0x00, 0xB6, 0xD0, 0x68, 0x3E, 0x80, 0x2F, 0x0C, 0xA9, 0xFE, 0x64, 0x53, 0x69, 0x7A,
+}
+
+// encrypter is implemented by writers that encrypt the strings and streams of an indirect object as they are written.
+type encrypter interface {
+	encrypt(data []byte) []byte
+}
+
+type encryptingWriter struct {
+	io.Writer
+	sh  *securityHandler
+	key []byte
+}
+
+func (ew *encryptingWriter) encrypt(data []byte) []byte {
+	return ew.sh.encrypt(ew.key, data)
+}
+
+// securityHandler implements the standard security handler, deriving keys and the Encrypt dictionary from passwords.
+type securityHandler struct {
+	encryption    Encryption
+	userPassword  string
+	ownerPassword string
+	permissions   Permissions
+	rand          io.Reader
+	key           []byte
+	o, u          []byte
+	oe, ue, perms []byte
+	dict          *dictionaryObject
+}
+
+func newSecurityHandler(encryption Encryption, userPassword, ownerPassword string, permissions Permissions) *securityHandler {
+	if ownerPassword == "" {
+		ownerPassword = userPassword
+	}
+	return &securityHandler{
+		encryption:    encryption,
+		userPassword:  userPassword,
+		ownerPassword: ownerPassword,
+		permissions:   permissions,
+		rand:          rand.Reader,
+	}
+}
+
+// init derives the file encryption key and password entries. The first element of the file identifier is
+// used by revisions 3 and 4.
+func (sh *securityHandler) init(id []byte) error {
+	switch sh.encryption {
+	case RC4_128, AES_128:
+		sh.initStandard(id)
+		return nil
+	case AES_256:
+		return sh.initAES256()
+	}
+	return errUnknownEncryption
+}
+
+// initStandard implements algorithms 2, 3 and 5 of ISO 32000-1, 7.6.3.
+func (sh *securityHandler) initStandard(id []byte) {
+	user := padPassword(sh.userPassword)
+	owner := padPassword(sh.ownerPassword)
+
+	ownerKey := md5.Sum(owner)
+	for i := 0; i < 50; i++ {
+		ownerKey = md5.Sum(ownerKey[:])
+	}
+	sh.o = rc4Rounds(ownerKey[:], user)
+
+	p := sh.p()
+	h := md5.New()
+	h.Write(user)
+	h.Write(sh.o)
+	h.Write([]byte{byte(p), byte(p >> 8), byte(p >> 16), byte(p >> 24)})
+	h.Write(id)
+	key := h.Sum(nil)
+	for i := 0; i < 50; i++ {
+		sum := md5.Sum(key)
+		key = sum[:]
+	}
+	sh.key = key
+
+	h.Reset()
+	h.Write(passwordPadding)
+	h.Write(id)
+	sh.u = append(rc4Rounds(sh.key, h.Sum(nil)), make([]byte, 16)...)
+}
+
+// initAES256 implements algorithms 8, 9 and 10 of ISO 32000-2, 7.6.4.4.
+func (sh *securityHandler) initAES256() error {
+	random := make([]byte, 32+4*8+4)
+	if _, err := io.ReadFull(sh.rand, random); err != nil {
+		return err
+	}
+	sh.key = random[:32]
+	userValidationSalt, userKeySalt := random[32:40], random[40:48]
+	ownerValidationSalt, ownerKeySalt := random[48:56], random[56:64]
+
+	user := utf8Password(sh.userPassword)
+	sh.u = concat(hashR6(user, userValidationSalt, nil), userValidationSalt, userKeySalt)
+	sh.ue = aesNoPadding(hashR6(user, userKeySalt, nil), sh.key)
+
+	owner := utf8Password(sh.ownerPassword)
+	sh.o = concat(hashR6(owner, ownerValidationSalt, sh.u), ownerValidationSalt, ownerKeySalt)
+	sh.oe = aesNoPadding(hashR6(owner, ownerKeySalt, sh.u), sh.key)
+
+	p := sh.p()
+	perms := []byte{byte(p), byte(p >> 8), byte(p >> 16), byte(p >> 24), 0xFF, 0xFF, 0xFF, 0xFF, 'T', 'a', 'd', 'b'}
+	sh.perms = aesNoPadding(sh.key, append(perms, random[64:68]...))
+	return nil
+}
+
+// dictionary returns the Encrypt dictionary.
+func (sh *securityHandler) dictionary() dictionary {
+	d := dictionary{
+		"Filter": name("Standard"),
+		"O":      hexStr(sh.o),
+		"U":      hexStr(sh.u),
+		"P":      integer(sh.p()),
+	}
+	switch sh.encryption {
+	case RC4_128:
+		d["V"], d["R"], d["Length"] = integer(2), integer(3), integer(128)
+	case AES_128:
+		d["V"], d["R"], d["Length"] = integer(4), integer(4), integer(128)
+		d["CF"] = cryptFilters("AESV2", 16)
+		d["StmF"], d["StrF"] = name("StdCF"), name("StdCF")
+	case AES_256:
+		d["V"], d["R"], d["Length"] = integer(5), integer(6), integer(256)
+		d["CF"] = cryptFilters("AESV3", 32)
+		d["StmF"], d["StrF"] = name("StdCF"), name("StdCF")
+		d["OE"], d["UE"], d["Perms"] = hexStr(sh.oe), hexStr(sh.ue), hexStr(sh.perms)
+	}
+	return d
+}
+
+func cryptFilters(method string, length int) dictionary {
+	return dictionary{"StdCF": dictionary{
+		"CFM":       name(method),
+		"AuthEvent": name("DocOpen"),
+		"Length":    integer(length),
+	}}
+}
+
+func (sh *securityHandler) encrypt(key, data []byte) []byte {
+	if sh.encryption == RC4_128 {
+		c, _ := rc4.NewCipher(key)
+		out := make([]byte, len(data))
+		c.XORKeyStream(out, data)
+		return out
+	}
+	padding := aes.BlockSize - len(data)%aes.BlockSize
+	out := make([]byte, aes.BlockSize+len(data)+padding)
+	iv := out[:aes.BlockSize]
+	io.ReadFull(sh.rand, iv)
+	copy(out[aes.BlockSize:], data)
+	for i := len(out) - padding; i < len(out); i++ {
+		out[i] = byte(padding)
+	}
+	block, _ := aes.NewCipher(key)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(out[aes.BlockSize:], out[aes.BlockSize:])
+	return out
+}
+
+// objectKey returns the key for the strings and streams of an indirect object, as described by algorithm 1.
+func (sh *securityHandler) objectKey(seq, gen int) []byte {
+	if sh.encryption == AES_256 {
+		return sh.key
+	}
+	h := md5.New()
+	h.Write(sh.key)
+	h.Write([]byte{byte(seq), byte(seq >> 8), byte(seq >> 16), byte(gen), byte(gen >> 8)})
+	if sh.encryption == AES_128 {
+		h.Write([]byte("sAlT"))
+	}
+	return h.Sum(nil)
+}
+
+// p returns the value of P: the permissions plus the bits the specification requires to be set.
+func (sh *securityHandler) p() int32 {
+	return int32(uint32(sh.permissions&PermitAll) | 0xFFFFF0C0)
+}
+
+// writer returns a writer that encrypts the strings and streams of the specified indirect object.
+// The Encrypt dictionary itself is never encrypted.
+func (sh *securityHandler) writer(w io.Writer, obj genWriter) io.Writer {
+	if obj == genWriter(sh.dict) {
+		return w
+	}
+	return &encryptingWriter{w, sh, sh.objectKey(obj.Seq(), obj.Gen())}
+}
+
+// hashR6 implements algorithm 2.B of ISO 32000-2.
+func hashR6(password, salt, userKey []byte) []byte {
+	h := sha256.New()
+	h.Write(password)
+	h.Write(salt)
+	h.Write(userKey)
+	k := h.Sum(nil)
+	for round := 1; ; round++ {
+		k1 := bytes.Repeat(concat(password, k, userKey), 64)
+		block, _ := aes.NewCipher(k[:16])
+		e := make([]byte, len(k1))
+		cipher.NewCBCEncrypter(block, k[16:32]).CryptBlocks(e, k1)
+		var sum int
+		for _, b := range e[:16] {
+			sum += int(b)
+		}
+		var next hash.Hash
+		switch sum % 3 {
+		case 0:
+			next = sha256.New()
+		case 1:
+			next = sha512.New384()
+		case 2:
+			next = sha512.New()
+		}
+		next.Write(e)
+		k = next.Sum(nil)
+		if round >= 64 && int(e[len(e)-1]) <= round-32 {
+			break
+		}
+	}
+	return k[:32]
+}
+
+// aesNoPadding encrypts data, a multiple of the block size, with AES-256 in CBC mode with a zero initialization vector.
+func aesNoPadding(key, data []byte) []byte {
+	block, _ := aes.NewCipher(key)
+	out := make([]byte, len(data))
+	cipher.NewCBCEncrypter(block, make([]byte, aes.BlockSize)).CryptBlocks(out, data)
+	return out
+}
+
+func concat(slices ...[]byte) []byte {
+	return bytes.Join(slices, nil)
+}
+
+// padPassword encodes a password for revisions 3 and 4, truncating or padding it to 32 bytes.
+// Runes beyond Latin-1 are replaced with question marks.
+func padPassword(password string) []byte {
+	padded := make([]byte, 0, 32)
+	for _, r := range password {
+		if len(padded) == 32 {
+			break
+		}
+		if r > 0xFF {
+			r = '?'
+		}
+		padded = append(padded, byte(r))
+	}
+	return append(padded, passwordPadding[:32-len(padded)]...)
+}
+
+// rc4Rounds encrypts data with key, then 19 more times with each byte of key XORed with the round number.
+func rc4Rounds(key, data []byte) []byte {
+	out := make([]byte, len(data))
+	roundKey := make([]byte, len(key))
+	copy(out, data)
+	for round := 0; round < 20; round++ {
+		for i := range key {
+			roundKey[i] = key[i] ^ byte(round)
+		}
+		c, _ := rc4.NewCipher(roundKey)
+		c.XORKeyStream(out, out)
+	}
+	return out
+}
+
+// utf8Password truncates a password for revision 6 to 127 bytes. SASLprep normalization is not applied.
+func utf8Password(password string) []byte {
+	b := []byte(password)
+	if len(b) > 127 {
+		b = b[:127]
+	}
+	return b
+}
+
+// SetEncryption encrypts the document with the standard security handler when it is written.
+// Users opening the document with the user password, which may be empty, are limited to the given permissions;
+// the owner password, which defaults to the user password, grants them all. NoEncryption turns encryption off.
+// A streaming DocWriter must have its encryption set before its first page is written.
+// PDF/A documents cannot be encrypted.
+func (dw *DocWriter) SetEncryption(encryption Encryption, userPassword, ownerPassword string, permissions Permissions) error {
+	if dw.file.out != nil {
+		return errEncryptionTooLate
+	}
+	if dw.pdfa && encryption != NoEncryption {
+		return errPDFAEncryption
+	}
+	switch encryption {
+	case NoEncryption:
+		dw.security = nil
+	case RC4_128, AES_128, AES_256:
+		dw.security = newSecurityHandler(encryption, userPassword, ownerPassword, permissions)
+	default:
+		return errUnknownEncryption
+	}
+	return nil
+}
+
+// encrypt adds the Encrypt dictionary to the document, if encryption was requested,
+// and raises the PDF version as the encryption method requires.
+func (dw *DocWriter) encrypt(id []byte) error {
+	sh := dw.security
+	if sh == nil {
+		return nil
+	}
+	if err := sh.init(id); err != nil {
+		return err
+	}
+	sh.dict = newDictionaryObject(dw.nextSeq(), 0)
+	sh.dict.dict = sh.dictionary()
+	dw.file.body.add(sh.dict)
+	dw.file.body.security = sh
+	dw.file.trailer.setEncrypt(sh.dict)
+	switch sh.encryption {
+	case RC4_128:
+		dw.file.header.requireVersion(1.4)
+	case AES_128:
+		dw.file.header.requireVersion(1.6)
+	case AES_256:
+		dw.file.header.requireVersion(1.7)
+		dw.catalog.setExtensions(dictionary{"ADBE": dictionary{
+			"BaseVersion":    name("1.7"),
+			"ExtensionLevel": integer(8),
+		}})
+	}
+	return nil
+}
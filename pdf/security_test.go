@@ -0,0 +1,165 @@
+// Copyright 2015 Brent Rowland.
+// Use of this source code is governed the Apache License, Version 2.0, as described in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// sequence is a predictable source of "random" bytes for tests.
+type sequence byte
+
+func (s *sequence) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = byte(*s)
+		*s++
+	}
+	return len(p), nil
+}
+
+func testID() []byte {
+	id := make([]byte, 16)
+	for i := range id {
+		id[i] = byte(i)
+	}
+	return id
+}
+
+func TestPadPassword(t *testing.T) {
+	expectS(t, string(passwordPadding), string(padPassword("")))
+	expectS(t, "user"+string(passwordPadding[:28]), string(padPassword("user")))
+	expectS(t, "caf\xE9?", string(padPassword("café中"))[:5])
+	expectI(t, 32, len(padPassword(strings.Repeat("x", 40))))
+}
+
+func TestSecurityHandler_p(t *testing.T) {
+	sh := newSecurityHandler(RC4_128, "", "", 0)
+	expectI(t, -3904, int(sh.p()))
+	sh = newSecurityHandler(RC4_128, "", "", PermitAll)
+	expectI(t, -4, int(sh.p()))
+	sh = newSecurityHandler(RC4_128, "", "", PermitPrint|PermitCopy|1)
+	expectI(t, -3884, int(sh.p()))
+}
+
+// Expected values were computed independently from algorithms 2, 3 and 5 of ISO 32000-1.
+func TestSecurityHandler_initStandard(t *testing.T) {
+	sh := newSecurityHandler(RC4_128, "user", "owner", PermitPrint|PermitCopy)
+	checkFatal(t, sh.init(testID()) == nil, "init should succeed.")
+	expectS(t, "0BA3835F88F90388E74E54584125CE142BE0DE24C6B0D37746E075B891756671", fmt.Sprintf("%X", sh.o))
+	expectS(t, "A9FC820563D3703C38129863A537A41400000000000000000000000000000000", fmt.Sprintf("%X", sh.u))
+	expectS(t, "746E1C7ECA6ADA9B48AFD690ED4E77EA", fmt.Sprintf("%X", sh.key))
+	expectS(t, "F4B59B6678", fmt.Sprintf("%X", sh.encrypt(sh.objectKey(7, 0), []byte("Hello"))))
+}
+
+// Expected values were computed independently from algorithm 2.B of ISO 32000-2.
+func TestHashR6(t *testing.T) {
+	expectS(t, "33A74805A1940282CA67D2B4938A4F77DB6F69C75E92E9F281F0743EF0111571",
+		fmt.Sprintf("%X", hashR6([]byte("user"), []byte("12345678"), nil)))
+	userKey := make([]byte, 48)
+	for i := range userKey {
+		userKey[i] = byte(i)
+	}
+	expectS(t, "E4EB4CB643A70D7B4AA20DFDD1448EC14283E6184D750BB804BB60F7C6A7F762",
+		fmt.Sprintf("%X", hashR6([]byte("owner"), []byte("abcdefgh"), userKey)))
+}
+
+func aesDecrypt(key, iv, data []byte) []byte {
+	block, _ := aes.NewCipher(key)
+	out := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(out, data)
+	return out
+}
+
+func TestSecurityHandler_initAES256(t *testing.T) {
+	var s sequence
+	sh := newSecurityHandler(AES_256, "user", "owner", PermitPrint)
+	sh.rand = &s
+	checkFatal(t, sh.init(nil) == nil, "init should succeed.")
+	expectI(t, 48, len(sh.u))
+	expectI(t, 48, len(sh.o))
+	zeroIV := make([]byte, aes.BlockSize)
+
+	// Algorithm 11: authenticate the user password, then recover the file key from UE.
+	expectS(t, fmt.Sprintf("%X", sh.u[:32]), fmt.Sprintf("%X", hashR6([]byte("user"), sh.u[32:40], nil)))
+	key := aesDecrypt(hashR6([]byte("user"), sh.u[40:48], nil), zeroIV, sh.ue)
+	expectS(t, fmt.Sprintf("%X", sh.key), fmt.Sprintf("%X", key))
+
+	// Algorithm 12: authenticate the owner password, then recover the file key from OE.
+	expectS(t, fmt.Sprintf("%X", sh.o[:32]), fmt.Sprintf("%X", hashR6([]byte("owner"), sh.o[32:40], sh.u)))
+	key = aesDecrypt(hashR6([]byte("owner"), sh.o[40:48], sh.u), zeroIV, sh.oe)
+	expectS(t, fmt.Sprintf("%X", sh.key), fmt.Sprintf("%X", key))
+
+	// Algorithm 13: validate the permissions.
+	perms := aesDecrypt(sh.key, zeroIV, sh.perms)
+	expectS(t, "Tadb", string(perms[8:12]))
+	expectS(t, "C4F0FFFFFFFFFFFF", fmt.Sprintf("%X", perms[:8]))
+}
+
+func TestSecurityHandler_encrypt_AES(t *testing.T) {
+	var s sequence
+	sh := newSecurityHandler(AES_128, "", "", PermitAll)
+	sh.rand = &s
+	checkFatal(t, sh.init(testID()) == nil, "init should succeed.")
+	key := sh.objectKey(3, 0)
+	expectI(t, 16, len(key))
+	for _, plain := range []string{"", "Hello", "0123456789ABCDEF"} {
+		encrypted := sh.encrypt(key, []byte(plain))
+		expectI(t, 16+(len(plain)/16+1)*16, len(encrypted))
+		decrypted := aesDecrypt(key, encrypted[:16], encrypted[16:])
+		padding := int(decrypted[len(decrypted)-1])
+		expectS(t, plain, string(decrypted[:len(decrypted)-padding]))
+	}
+}
+
+func TestSecurityHandler_dictionary(t *testing.T) {
+	sh := newSecurityHandler(AES_128, "", "", PermitAll)
+	checkFatal(t, sh.init(testID()) == nil, "init should succeed.")
+	d := stringFromWriter(sh.dictionary())
+	check(t, strings.Contains(d, "/CF <<\n/StdCF <<\n/AuthEvent /DocOpen \n/CFM /AESV2 \n/Length 16 \n>>\n\n>>\n"), "Encrypt should contain AESV2 crypt filter.")
+	check(t, strings.Contains(d, "/Filter /Standard "), "Encrypt should use standard handler.")
+	check(t, strings.Contains(d, "/R 4 "), "Encrypt should be revision 4.")
+	check(t, strings.Contains(d, "/StmF /StdCF "), "Encrypt should set stream filter.")
+	check(t, strings.Contains(d, "/V 4 "), "Encrypt should be version 4.")
+}
+
+func TestDocWriter_SetEncryption(t *testing.T) {
+	dw := NewDocWriter()
+	check(t, dw.SetEncryption(Encryption(99), "", "", 0) == errUnknownEncryption, "Unknown encryption should fail.")
+	checkFatal(t, dw.SetEncryption(RC4_128, "", "secret", PermitPrint) == nil, "SetEncryption should succeed.")
+	dw.SetCompressionLevel(NoCompression)
+	dw.SetInfo(Info{Title: "Secret Report"})
+
+	var buf bytes.Buffer
+	_, err := dw.WriteTo(&buf)
+	checkFatal(t, err == nil, "WriteTo should succeed.")
+	pdf := buf.String()
+	check(t, strings.HasPrefix(pdf, "%PDF-1.4\n"), "Version should be raised to 1.4.")
+	check(t, !strings.Contains(pdf, "Secret Report"), "Title should be encrypted.")
+	check(t, !strings.Contains(pdf, "<x:xmpmeta"), "Metadata should be encrypted.")
+	check(t, strings.Contains(pdf, "/Filter /Standard "), "Encrypt dictionary should not be encrypted.")
+	trailer := pdf[strings.LastIndex(pdf, "trailer"):]
+	check(t, strings.Contains(trailer, "/Encrypt "), "Trailer should reference Encrypt.")
+	check(t, strings.Contains(trailer, "/ID [<"), "Trailer should contain ID.")
+
+	sh := dw.security
+	infoDict := dw.file.trailer.dict["Info"].(*indirectObjectRef)
+	key := sh.objectKey(infoDict.obj.Seq(), infoDict.obj.Gen())
+	expected := fmt.Sprintf("/Title <%X> ", sh.encrypt(key, []byte("Secret Report")))
+	check(t, strings.Contains(pdf, expected), "Title should be encrypted with the Info dictionary's key.")
+
+	dw = NewDocWriter()
+	checkFatal(t, dw.SetEncryption(AES_256, "", "secret", PermitPrint) == nil, "SetEncryption should succeed.")
+	buf.Reset()
+	_, err = dw.WriteTo(&buf)
+	checkFatal(t, err == nil, "WriteTo should succeed.")
+	pdf = buf.String()
+	check(t, strings.HasPrefix(pdf, "%PDF-1.7\n"), "Version should be raised to 1.7.")
+	check(t, strings.Contains(pdf, "/ExtensionLevel 8 "), "Catalog should declare ADBE extension level 8.")
+	check(t, strings.Contains(pdf, "/CFM /AESV3 "), "Encrypt should use AESV3 crypt filter.")
+}
@@ -0,0 +1,190 @@
+// Copyright 2015 Brent Rowland.
+// Use of this source code is governed the Apache License, Version 2.0, as described in the LICENSE file.
+
+package pdf
+
+import (
+	"errors"
+	"math"
+)
+
+var (
+	errTooFewPolygonPoints  = errors.New("Need at least 3 points for polygon.")
+	errTooFewPolylinePoints = errors.New("Need at least 2 points for polyline.")
+	errTooFewStarPoints     = errors.New("Need at least 2 points for star.")
+)
+
+// Angles are measured in degrees counterclockwise from the positive x axis, as for Rotate and SetTextAngle.
+// Shapes are drawn counterclockwise on the page unless reverse is true, so that a reversed shape within another
+// is knocked out of it when filled using the nonzero winding rule.
+
+// arcPoints returns the points of Bézier curves, as for CurvePoints, approximating an arc of radius r centred at x, y,
+// running from startAngle to endAngle, at most a quarter turn per curve.
+func arcPoints(x, y, r, startAngle, endAngle float64) []Location {
+	sweep := endAngle - startAngle
+	n := int(math.Ceil(math.Abs(sweep) / 90))
+	if n == 0 {
+		n = 1
+	}
+	step := sweep / float64(n) * math.Pi / 180
+	k := 4.0 / 3.0 * math.Tan(step/4) * r
+	// y increases down the page, so counterclockwise is toward smaller y.
+	point := func(a float64) Location {
+		return Location{x + r*math.Cos(a), y - r*math.Sin(a)}
+	}
+	a := startAngle * math.Pi / 180
+	p0 := point(a)
+	points := []Location{p0}
+	for i := 0; i < n; i++ {
+		p3 := point(a + step)
+		points = append(points,
+			Location{p0.X - k*math.Sin(a), p0.Y - k*math.Cos(a)},
+			Location{p3.X + k*math.Sin(a+step), p3.Y + k*math.Cos(a+step)},
+			p3)
+		a += step
+		p0 = p3
+	}
+	return points
+}
+
+// startShape ends any text or path in progress and sets the colours and line style a shape is painted with.
+func (pw *PageWriter) startShape(border, fill bool) {
+	pw.startGraph()
+	if pw.inPath && pw.autoPath {
+		pw.gw.stroke()
+		pw.inPath = false
+	}
+	if border {
+		pw.checkSetLineColor()
+		pw.checkSetLineWidth()
+		pw.checkSetLineDashPattern()
+	}
+	if fill {
+		pw.checkSetFillColor()
+	}
+}
+
+// Arc continues the path along an arc of radius r centred at x, y from startAngle to endAngle,
+// running clockwise if endAngle is less than startAngle.
+// The arc starts a new path unless the path already ends where the arc begins.
+func (pw *PageWriter) Arc(x, y, r, startAngle, endAngle float64) {
+	pw.startGraph()
+	pw.CurvePoints(arcPoints(x, y, r, startAngle, endAngle))
+}
+
+// Circle draws a circle of radius r centred at x, y.
+func (pw *PageWriter) Circle(x, y, r float64, border, fill, reverse bool) {
+	pw.Ellipse(x, y, r, r, border, fill, reverse)
+}
+
+// Ellipse draws an ellipse centred at x, y with horizontal radius rx and vertical radius ry.
+func (pw *PageWriter) Ellipse(x, y, rx, ry float64, border, fill, reverse bool) {
+	pw.startShape(border, fill)
+	pw.ellipsePath(x, y, rx, ry, reverse)
+	pw.autoStrokeAndFill(border, fill)
+	pw.MoveTo(x, y)
+}
+
+// Pie draws a slice of a circle of radius r centred at x, y, running counterclockwise from startAngle to endAngle.
+func (pw *PageWriter) Pie(x, y, r, startAngle, endAngle float64, border, fill, reverse bool) {
+	pw.startShape(border, fill)
+	for endAngle < startAngle {
+		endAngle += 360
+	}
+	if reverse {
+		startAngle, endAngle = endAngle, startAngle
+	}
+	points := arcPoints(x, y, r, startAngle, endAngle)
+	pw.MoveTo(x, y)
+	pw.LineTo(points[0].X, points[0].Y)
+	pw.CurvePoints(points)
+	pw.LineTo(x, y)
+	pw.autoStrokeAndFill(border, fill)
+}
+
+// Polygon draws a closed shape through points, returning to the first.
+func (pw *PageWriter) Polygon(points []Location, border, fill, reverse bool) error {
+	if len(points) < 3 {
+		return errTooFewPolygonPoints
+	}
+	pw.startShape(border, fill)
+	pw.polygonPath(points, reverse)
+	pw.autoStrokeAndFill(border, fill)
+	return nil
+}
+
+// polygonPath draws lines through points, in reverse order if reverse is true, and back to the first.
+func (pw *PageWriter) polygonPath(points []Location, reverse bool) {
+	if reverse {
+		points = append(LocationSlice(nil), points...)
+		LocationSlice(points).Reverse()
+	}
+	pw.MoveTo(points[0].X, points[0].Y)
+	for _, p := range points[1:] {
+		pw.LineTo(p.X, p.Y)
+	}
+	pw.LineTo(points[0].X, points[0].Y)
+}
+
+// Polyline continues the path with lines through points, starting a new path unless the path already ends
+// at the first point.
+func (pw *PageWriter) Polyline(points []Location) error {
+	if len(points) < 2 {
+		return errTooFewPolylinePoints
+	}
+	pw.MoveTo(points[0].X, points[0].Y)
+	for _, p := range points[1:] {
+		pw.LineTo(p.X, p.Y)
+	}
+	return nil
+}
+
+// Star draws a star of n points centred at x, y, with its points at radius r1 and the angles between them
+// at radius r2. The first point is straight up.
+func (pw *PageWriter) Star(x, y, r1, r2 float64, n int, border, fill, reverse bool) error {
+	if n < 2 {
+		return errTooFewStarPoints
+	}
+	points := make([]Location, 2*n)
+	for i := range points {
+		r := r1
+		if i%2 == 1 {
+			r = r2
+		}
+		a := math.Pi/2 + float64(i)*math.Pi/float64(n)
+		points[i] = Location{x + r*math.Cos(a), y - r*math.Sin(a)}
+	}
+	pw.startShape(border, fill)
+	pw.polygonPath(points, reverse)
+	pw.autoStrokeAndFill(border, fill)
+	pw.MoveTo(x, y)
+	return nil
+}
+
+func (dw *DocWriter) Arc(x, y, r, startAngle, endAngle float64) {
+	dw.CurPage().Arc(x, y, r, startAngle, endAngle)
+}
+
+func (dw *DocWriter) Circle(x, y, r float64, border, fill, reverse bool) {
+	dw.CurPage().Circle(x, y, r, border, fill, reverse)
+}
+
+func (dw *DocWriter) Ellipse(x, y, rx, ry float64, border, fill, reverse bool) {
+	dw.CurPage().Ellipse(x, y, rx, ry, border, fill, reverse)
+}
+
+func (dw *DocWriter) Pie(x, y, r, startAngle, endAngle float64, border, fill, reverse bool) {
+	dw.CurPage().Pie(x, y, r, startAngle, endAngle, border, fill, reverse)
+}
+
+func (dw *DocWriter) Polygon(points []Location, border, fill, reverse bool) error {
+	return dw.CurPage().Polygon(points, border, fill, reverse)
+}
+
+func (dw *DocWriter) Polyline(points []Location) error {
+	return dw.CurPage().Polyline(points)
+}
+
+func (dw *DocWriter) Star(x, y, r1, r2 float64, n int, border, fill, reverse bool) error {
+	return dw.CurPage().Star(x, y, r1, r2, n, border, fill, reverse)
+}
@@ -0,0 +1,101 @@
+// Copyright 2015 Brent Rowland.
+// Use of this source code is governed the Apache License, Version 2.0, as described in the LICENSE file.
+
+package pdf
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/rowland/leadtype/colors"
+	"github.com/rowland/leadtype/options"
+)
+
+func TestArcPoints(t *testing.T) {
+	points := arcPoints(0, 0, 100, 0, 90)
+	expectI(t, 4, len(points))
+	expectF(t, 100, points[0].X)
+	expectF(t, 0, points[0].Y)
+	check(t, math.Abs(points[3].X) < 1e-9, "Arc should end straight up.")
+	expectF(t, -100, points[3].Y)
+	// The control points of a quarter circle lie 0.5523 of the radius along the tangents.
+	check(t, math.Abs(points[1].Y+55.2285) < 1e-4, "First control point should be on tangent at start.")
+	check(t, math.Abs(points[2].X-55.2285) < 1e-4, "Second control point should be on tangent at end.")
+
+	expectI(t, 7, len(arcPoints(0, 0, 100, 0, 135)))
+	cw := arcPoints(0, 0, 100, 0, -90)
+	expectF(t, 100, cw[3].Y)
+}
+
+func TestPageWriter_Circle(t *testing.T) {
+	dw := NewDocWriter()
+	pw := newPageWriter(dw, options.Options{})
+	pw.SetFillColor(colors.Red)
+	pw.Circle(100, 100, 50, true, true, false)
+	s := pw.stream.String()
+	check(t, strings.HasPrefix(s, "1 0 0 rg\n150 692 m\n"), "Circle should set fill colour and start at right.")
+	expectI(t, 4, strings.Count(s, " c\n"))
+	check(t, strings.HasSuffix(s, "150 692 c\nB\n"), "Circle should be filled and stroked.")
+	expectF(t, 100, pw.X())
+	expectF(t, 100, pw.Y())
+
+	pw.stream.Reset()
+	pw.Circle(100, 100, 25, false, true, true)
+	s = pw.stream.String()
+	check(t, strings.HasPrefix(s, "125 692 m\n125 678.1929 "), "Reversed circle should run clockwise, down the page first.")
+	check(t, strings.HasSuffix(s, "f\n"), "Circle should be filled.")
+}
+
+func TestPageWriter_Circle_units(t *testing.T) {
+	dw := NewDocWriter()
+	pw := newPageWriter(dw, options.Options{"units": "in"})
+	pw.Circle(1, 1, 0.5, true, false, false)
+	s := pw.stream.String()
+	check(t, strings.HasPrefix(s, "108 720 m\n"), "Circle should start at right, in points.")
+	check(t, strings.HasSuffix(s, "108 720 c\nS\n"), "Circle should end where it started.")
+	expectF(t, 1, pw.X())
+	expectF(t, 1, pw.Y())
+}
+
+func TestPageWriter_Pie(t *testing.T) {
+	dw := NewDocWriter()
+	pw := newPageWriter(dw, options.Options{})
+	pw.Pie(100, 100, 50, 0, 90, true, false, false)
+	s := pw.stream.String()
+	check(t, strings.HasPrefix(s, "100 692 m\n150 692 l\n"), "Pie should start from centre.")
+	expectI(t, 1, strings.Count(s, " c\n"))
+	check(t, strings.HasSuffix(s, " 742 c\n100 692 l\nS\n"), "Pie should return to centre.")
+}
+
+func TestPageWriter_Polygon(t *testing.T) {
+	dw := NewDocWriter()
+	pw := newPageWriter(dw, options.Options{})
+	points := []Location{{0, 0}, {100, 0}, {0, 100}}
+	check(t, pw.Polygon(points[:2], true, false, false) == errTooFewPolygonPoints, "Polygon should need 3 points.")
+	check(t, pw.Polygon(points, true, false, true) == nil, "Polygon should succeed.")
+	expectS(t, "0 692 m\n100 792 l\n0 792 l\n0 692 l\nS\n", pw.stream.String())
+	expectF(t, 0, points[0].X)
+	expectF(t, 100, points[1].X)
+}
+
+func TestPageWriter_Polyline(t *testing.T) {
+	dw := NewDocWriter()
+	pw := newPageWriter(dw, options.Options{})
+	check(t, pw.Polyline([]Location{{0, 0}}) == errTooFewPolylinePoints, "Polyline should need 2 points.")
+	pw.Polyline([]Location{{0, 0}, {100, 0}})
+	pw.Polyline([]Location{{100, 0}, {100, 100}})
+	pw.endGraph()
+	expectS(t, "0 792 m\n100 792 l\n100 692 l\nS\n", pw.stream.String())
+}
+
+func TestPageWriter_Star(t *testing.T) {
+	dw := NewDocWriter()
+	pw := newPageWriter(dw, options.Options{})
+	check(t, pw.Star(100, 100, 50, 20, 1, false, true, false) == errTooFewStarPoints, "Star should need 2 points.")
+	check(t, pw.Star(100, 100, 50, 20, 5, false, true, false) == nil, "Star should succeed.")
+	s := pw.stream.String()
+	check(t, strings.HasPrefix(s, "100 742 m\n"), "Star should start at top point.")
+	expectI(t, 10, strings.Count(s, " l\n"))
+	check(t, strings.HasSuffix(s, "100 742 l\nf\n"), "Star should be closed and filled.")
+}
@@ -0,0 +1,17 @@
+// Copyright 2015 Brent Rowland.
+// Use of this source code is governed the Apache License, Version 2.0, as described in the LICENSE file.
+
+package pdf
+
+import (
+	_ "embed"
+)
+
+// srgbIdentifier names the output condition of the sRGB profile in the ICC registry.
+const srgbIdentifier = "sRGB IEC61966-2.1"
+
+// srgbProfile is the sRGB IEC61966-2.1 display profile created by Graeme W. Gill for ArgyllCMS
+// and released into the public domain, embedded unchanged.
+//
+//go:embed data/sRGB.icc
+var srgbProfile []byte
@@ -0,0 +1,100 @@
+// Copyright 2015 Brent Rowland.
+// Use of this source code is governed the Apache License, Version 2.0, as described in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"time"
+)
+
+var (
+	errNotStreaming = errors.New("DocWriter is not bound to a writer; use WriteTo instead of Close.")
+	errStreaming    = errors.New("DocWriter is bound to a writer; use Close instead of WriteTo.")
+)
+
+// NewStreamingDocWriter returns a DocWriter bound to w, for documents too large to hold in memory.
+// Each page is written to w, and its content released, as soon as the next page is started.
+// Objects shared by pages, such as fonts, the page tree and links to other pages, are written by Close.
+// Pages must not be drawn on once a later page has been started, and new pages can only follow the current one.
+func NewStreamingDocWriter(w io.Writer) *DocWriter {
+	dw := NewDocWriter()
+	dw.output = w
+	return dw
+}
+
+// Close finishes a document created with NewStreamingDocWriter, writing the current page,
+// the objects shared by all pages, and the cross-reference table and trailer.
+func (dw *DocWriter) Close() error {
+	if dw.output == nil {
+		return errNotStreaming
+	}
+	if len(dw.pages) == 0 {
+		dw.NewPage()
+	}
+	dw.finishPage()
+	if err := dw.finish(); err != nil {
+		return err
+	}
+	if dw.file.header.Version > dw.file.headerVersion {
+		dw.catalog.setVersion(dw.file.header.Version)
+	}
+	dw.file.finish()
+	return dw.file.out.err
+}
+
+// begin writes the file header, first setting up encryption, which determines both the version
+// and how every object is written. The file identifier is derived here, as encryption depends on it.
+func (dw *DocWriter) begin() error {
+	if dw.security != nil {
+		info := dw.infoAt(time.Now())
+		dw.id = info.fileID(0)
+		if err := dw.encrypt(dw.id); err != nil {
+			return err
+		}
+	}
+	if dw.objectStreams {
+		dw.file.header.requireVersion(1.5)
+	}
+	dw.file.begin(dw.output)
+	return nil
+}
+
+// finishPage closes the current page of a streaming DocWriter, writes its page object, content and complete
+// link annotations, and releases the page. Links to destinations are written by Close, once resolved.
+func (dw *DocWriter) finishPage() {
+	pw := dw.curPage
+	if dw.output == nil || pw == nil || dw.err != nil {
+		return
+	}
+	dw.curPage = nil
+	if dw.file.out == nil {
+		if err := dw.begin(); err != nil {
+			dw.setErr(err)
+			return
+		}
+	}
+	pw.close()
+	objs := []genWriter{pw.page}
+	for _, s := range pw.page.contents {
+		objs = append(objs, s)
+	}
+	for _, annot := range pw.page.annotations() {
+		if annot.hasTarget() {
+			objs = append(objs, annot)
+		}
+	}
+	dw.file.flush(objs...)
+	pw.page.contents = nil
+	pw.stream = bytes.Buffer{}
+	// Pages are finished in order, each the last of dw.pages not yet released, so the released pages come first.
+	// Only what destinations on them need is kept.
+	dw.catalog.pages.release(pw.page)
+	dw.flushed = append(dw.flushed, pw.target())
+	dw.pages[len(dw.flushed)-1] = nil
+	if dw.file.out.err != nil {
+		dw.setErr(dw.file.out.err)
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2015 Brent Rowland.
+// Use of this source code is governed the Apache License, Version 2.0, as described in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// checkXRefTable verifies that each in-use entry of the xref table locates the object it numbers.
+func checkXRefTable(t *testing.T, pdf string) {
+	start := strings.LastIndex(pdf, "\nxref\n") + 1
+	checkFatal(t, start > 0, "Document should have an xref table.")
+	lines := strings.Split(pdf[start:], "\n")
+	var first, count int
+	fmt.Sscanf(lines[1], "%d %d", &first, &count)
+	for i := 0; i < count; i++ {
+		var offset, gen int
+		var kind string
+		fmt.Sscanf(lines[2+i], "%d %d %s", &offset, &gen, &kind)
+		if kind == "n" {
+			check(t, strings.HasPrefix(pdf[offset:], fmt.Sprintf("%d %d obj\n", first+i, gen)),
+				fmt.Sprintf("Object %d should be at offset %d.", first+i, offset))
+		}
+	}
+	m := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindStringSubmatch(pdf)
+	checkFatal(t, m != nil, "Document should end with startxref.")
+	offset, _ := strconv.Atoi(m[1])
+	expectI(t, start, offset)
+}
+
+func TestNewStreamingDocWriter(t *testing.T) {
+	var buf bytes.Buffer
+	dw := NewStreamingDocWriter(&buf)
+	dw.SetCompressionLevel(NoCompression)
+	p1 := dw.NewPage()
+	dw.MoveTo(72, 72)
+	dw.LineTo(144, 144)
+	dw.AddLink(72, 72, 72, 72, "http://example.com")
+	dw.AddPageLink(72, 144, 72, 72, 2, 0)
+	expectI(t, 0, buf.Len())
+
+	dw.NewPage()
+	pdf := buf.String()
+	check(t, strings.HasPrefix(pdf, "%PDF-1.3\n"), "Header should be written with the first page.")
+	check(t, strings.Contains(pdf, "144 648 l"), "First page content should be written.")
+	check(t, strings.Contains(pdf, "/URI (http://example.com) "), "Link to URI should be written.")
+	check(t, !strings.Contains(pdf, "/Dest "), "Link to page should wait for Close.")
+	check(t, !strings.Contains(pdf, "/Type /Pages "), "Page tree should wait for Close.")
+	check(t, p1.page.contents == nil, "First page content should be released.")
+	check(t, dw.SetEncryption(RC4_128, "", "", PermitAll) == errEncryptionTooLate, "Encryption should be too late.")
+
+	dw.MoveTo(72, 72)
+	dw.LineTo(288, 288)
+	check(t, dw.Close() == nil, "Close should succeed.")
+	pdf = buf.String()
+	check(t, strings.Contains(pdf, "288 504 l"), "Last page content should be written.")
+	check(t, strings.Contains(pdf, "/Dest ["), "Link to page should be written.")
+	check(t, strings.Contains(pdf, "/Count 2 "), "Page tree should be written.")
+	checkXRefTable(t, pdf)
+
+	_, err := dw.WriteTo(&buf)
+	check(t, err == errStreaming, "WriteTo should fail for streaming DocWriter.")
+	check(t, NewDocWriter().Close() == errNotStreaming, "Close should fail for DocWriter without writer.")
+}
+
+func TestNewStreamingDocWriter_encrypted(t *testing.T) {
+	var buf bytes.Buffer
+	dw := NewStreamingDocWriter(&buf)
+	dw.SetCompressionLevel(NoCompression)
+	checkFatal(t, dw.SetEncryption(AES_128, "", "secret", PermitPrint) == nil, "SetEncryption should succeed.")
+	dw.MoveTo(72, 72)
+	dw.LineTo(144, 144)
+	dw.NewPage()
+	check(t, strings.HasPrefix(buf.String(), "%PDF-1.6\n"), "Header should have version required by encryption.")
+	check(t, !strings.Contains(buf.String(), "144 648 l"), "First page content should be encrypted.")
+	check(t, dw.Close() == nil, "Close should succeed.")
+	pdf := buf.String()
+	check(t, strings.Contains(pdf, "/Encrypt "), "Trailer should reference Encrypt.")
+	checkXRefTable(t, pdf)
+}
+
+func TestNewStreamingDocWriter_objectStreams(t *testing.T) {
+	var buf bytes.Buffer
+	dw := NewStreamingDocWriter(&buf)
+	dw.NewPage()
+	dw.NewPage()
+	dw.SetObjectStreams(true)
+	check(t, dw.Close() == nil, "Close should succeed.")
+	pdf := buf.String()
+	check(t, strings.HasPrefix(pdf, "%PDF-1.3\n"), "Header should have been written before object streams were requested.")
+	check(t, strings.Contains(pdf, "/Type /XRef "), "Document should have a cross-reference stream.")
+	check(t, !strings.Contains(pdf, "/Type /Catalog "), "Catalog should be compressed, with its version raised to 1.5.")
+}
+
+func TestNewStreamingDocWriter_releasePages(t *testing.T) {
+	var buf bytes.Buffer
+	dw := NewStreamingDocWriter(&buf)
+	dw.SetCompressionLevel(NoCompression)
+	p1 := dw.NewPage()
+	dw.AddDestination("top", 0)
+	p2 := dw.NewPage()
+	check(t, p2 != nil, "NewPage should follow a released page.")
+	check(t, dw.pages[0] == nil, "First page should be released.")
+	check(t, dw.catalog.pages.kids[0] != p1.page, "Page tree should not hold on to the first page.")
+	check(t, dw.NewPageAfter(p1) == nil, "New pages should not follow a released page.")
+	dw.AddPageLink(72, 72, 72, 72, 1, 72)
+	dw.AddDestLink(72, 144, 72, 72, "top")
+	_, err := dw.AddBookmark("First", 1, -1, nil)
+	check(t, err == nil, "Bookmark to released page should succeed.")
+	checkFatal(t, dw.Close() == nil, "Close should succeed.")
+
+	pdf := buf.String()
+	pageRef := stringFromWriter(&indirectObjectRef{p1.page})
+	check(t, strings.Contains(pdf, "/Dest ["+pageRef+"/XYZ null 720 null ] "), "Link to released page should be resolved.")
+	check(t, strings.Contains(pdf, "/Dest ["+pageRef+"/XYZ null 792 null ] "), "Link to destination on released page should be resolved.")
+	check(t, strings.Contains(pdf, "/Kids ["+pageRef), "Page tree should refer to released page.")
+	checkXRefTable(t, pdf)
+}
@@ -0,0 +1,46 @@
+// Copyright 2015 Brent Rowland.
+// Use of this source code is governed the Apache License, Version 2.0, as described in the LICENSE file.
+
+package pdf
+
+import (
+	"errors"
+)
+
+var errNoTemplate = errors.New("No template begun.")
+
+// BeginTemplate starts a template, width by height in the document's units, returning a PageWriter with which to draw it.
+// Until EndTemplate is called, the DocWriter's drawing methods also draw on the template.
+// Templates may be nested. Links and destinations are annotations of pages rather than content, so templates leave them out.
+func (dw *DocWriter) BeginTemplate(width, height float64) *PageWriter {
+	pw := new(PageWriter).initWriter(dw, dw.options)
+	pw.pageWidth = pw.units.toPts(width)
+	pw.pageHeight = pw.units.toPts(height)
+	// A template inherits the graphics state of the page it is printed on, so it sets its colours and transparency
+	// on first use rather than assuming the defaults.
+	pw.last.fillColor = nil
+	pw.last.lineColor = nil
+	pw.last.fillOpacity = -1
+	dw.templates = append(dw.templates, pw)
+	return pw
+}
+
+// EndTemplate finishes the template most recently begun, returning it as a Form to be printed on any page.
+func (dw *DocWriter) EndTemplate() (*Form, error) {
+	n := len(dw.templates)
+	if n == 0 || dw.templates[n-1].cell != nil {
+		return nil, errNoTemplate
+	}
+	pw := dw.templates[n-1]
+	dw.templates = dw.templates[:n-1]
+	pw.endText()
+	pw.endGraph()
+	pw.restoreStates()
+	pw.isClosed = true
+	xObject := newFormXObject(dw.nextSeq(), 0, &rectangle{0, 0, pw.pageWidth, pw.pageHeight}, pw.stream.Bytes())
+	xObject.setResources(&indirectObjectRef{dw.resources})
+	dw.compress(&xObject.stream)
+	dw.file.body.add(xObject)
+	pw.stream.Reset()
+	return dw.addForm(xObject, pw.pageWidth, pw.pageHeight), nil
+}
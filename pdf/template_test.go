@@ -0,0 +1,59 @@
+// Copyright 2015 Brent Rowland.
+// Use of this source code is governed the Apache License, Version 2.0, as described in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/rowland/leadtype/afm_fonts"
+	"github.com/rowland/leadtype/colors"
+	"github.com/rowland/leadtype/options"
+)
+
+func TestDocWriter_BeginTemplate(t *testing.T) {
+	fc, err := afm_fonts.New("../afm/data/fonts/*.afm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dw := NewDocWriter()
+	dw.AddFontSource(fc)
+	dw.SetCompressionLevel(NoCompression)
+	_, err = dw.EndTemplate()
+	check(t, err == errNoTemplate, "EndTemplate should fail without template.")
+
+	page := dw.NewPage()
+	page.SetFillColor(colors.Red)
+	tpl := dw.BeginTemplate(612, 72)
+	check(t, dw.CurPage() == tpl, "Template should be current while begun.")
+	expectF(t, 72, tpl.PageHeight())
+	dw.SetFont("Helvetica", 12, options.Options{})
+	dw.MoveTo(72, 36)
+	dw.Print("Header")
+	tpl.Rectangle(0, 0, 612, 72, true, false)
+	tpl.AddLink(72, 36, 72, 12, "http://example.com")
+	header, err := dw.EndTemplate()
+	checkFatal(t, err == nil, "EndTemplate should succeed.")
+	check(t, dw.CurPage() == page, "Page should be current again after EndTemplate.")
+	expectF(t, 612, header.Width())
+	expectF(t, 72, header.Height())
+
+	page.StampForm(header, nil)
+	dw.NewPage()
+	dw.StampForm(header, &Matrix{1, 0, 0, 1, 0, 720})
+	var buf bytes.Buffer
+	_, err = dw.WriteTo(&buf)
+	checkFatal(t, err == nil, "WriteTo should succeed.")
+	pdf := buf.String()
+	check(t, strings.Contains(pdf, "/BBox [0 0 612 72 ] "), "Form should have template's bounding box.")
+	check(t, strings.Contains(pdf, "/Subtype /Form "), "Template should be a form.")
+	check(t, strings.Contains(pdf, "0 0 0 rg\n"), "Template should set its own font colour.")
+	check(t, strings.Contains(pdf, "(Header) Tj"), "Template should contain text.")
+	check(t, strings.Contains(pdf, "0 0 612 72 re"), "Template should contain rectangle.")
+	check(t, strings.Contains(pdf, "/Fm0 Do\n"), "Form should be stamped.")
+	check(t, strings.Contains(pdf, "q\n1 0 0 1 0 720 cm\n/Fm0 Do\nQ\n"), "Form should be stamped with matrix.")
+	check(t, !strings.Contains(pdf, "/Annot"), "Template should leave out links.")
+	checkXRefTable(t, pdf)
+}
@@ -0,0 +1,77 @@
+// Copyright 2015 Brent Rowland.
+// Use of this source code is governed the Apache License, Version 2.0, as described in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"unicode/utf16"
+
+	"github.com/rowland/leadtype/codepage"
+)
+
+const toUnicodeHeader = `/CIDInit /ProcSet findresource begin
+12 dict begin
+begincmap
+/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def
+/CMapName /Adobe-Identity-UCS def
+/CMapType 2 def
+`
+
+const toUnicodeFooter = `endcmap
+CMapName currentdict /CMap defineresource pop
+end
+end
+`
+
+// Maximum number of entries permitted between beginbfchar and endbfchar.
+const maxBfChars = 100
+
+// toUnicodeCMap returns the text of a ToUnicode CMap mapping character codes of codeBytes bytes each to runes.
+// Codes mapped to rune 0 are omitted.
+func toUnicodeCMap(codeBytes int, mapping map[int]rune) []byte {
+	codes := make([]int, 0, len(mapping))
+	for code, r := range mapping {
+		if r != 0 {
+			codes = append(codes, code)
+		}
+	}
+	sort.Ints(codes)
+
+	var buf bytes.Buffer
+	buf.WriteString(toUnicodeHeader)
+	if codeBytes == 1 {
+		buf.WriteString("1 begincodespacerange\n<00> <FF>\nendcodespacerange\n")
+	} else {
+		buf.WriteString("1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	}
+	for len(codes) > 0 {
+		n := len(codes)
+		if n > maxBfChars {
+			n = maxBfChars
+		}
+		fmt.Fprintf(&buf, "%d beginbfchar\n", n)
+		for _, code := range codes[:n] {
+			fmt.Fprintf(&buf, "<%0*X> <", codeBytes*2, code)
+			for _, u := range utf16.Encode([]rune{mapping[code]}) {
+				fmt.Fprintf(&buf, "%04X", u)
+			}
+			buf.WriteString(">\n")
+		}
+		buf.WriteString("endbfchar\n")
+		codes = codes[n:]
+	}
+	buf.WriteString(toUnicodeFooter)
+	return buf.Bytes()
+}
+
+// codepageToUnicode maps the printable characters of a codepage to runes, as encoded for a simple font.
+func codepageToUnicode(cpi codepage.CodepageIndex) map[int]rune {
+	mapping := make(map[int]rune, 224)
+	for code, r := range cpi.Map()[32:256] {
+		mapping[code+32] = r
+	}
+	return mapping
+}
@@ -0,0 +1,42 @@
+// Copyright 2015 Brent Rowland.
+// Use of this source code is governed the Apache License, Version 2.0, as described in the LICENSE file.
+
+package pdf
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rowland/leadtype/codepage"
+)
+
+func TestToUnicodeCMap(t *testing.T) {
+	cmap := string(toUnicodeCMap(2, map[int]rune{36: 'A', 3: ' ', 5: 0, 1200: 0x1F600}))
+	expected := "1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n" +
+		"3 beginbfchar\n" +
+		"<0003> <0020>\n" +
+		"<0024> <0041>\n" +
+		"<04B0> <D83DDE00>\n" +
+		"endbfchar\n"
+	check(t, strings.HasPrefix(cmap, toUnicodeHeader), "CMap should begin with header.")
+	check(t, strings.Contains(cmap, expected), "CMap should map codes to UTF-16BE.")
+	check(t, strings.HasSuffix(cmap, toUnicodeFooter), "CMap should end with footer.")
+}
+
+func TestToUnicodeCMap_blocks(t *testing.T) {
+	cmap := string(toUnicodeCMap(1, codepageToUnicode(codepage.Idx_CP1252)))
+	check(t, strings.Contains(cmap, "<00> <FF>"), "CMap should have a 1-byte codespace.")
+	expectI(t, 3, strings.Count(cmap, "beginbfchar"))
+	check(t, strings.Contains(cmap, "100 beginbfchar\n<20> <0020>\n"), "1st block should start with space.")
+	check(t, strings.Contains(cmap, "<80> <20AC>\n"), "Euro sign should be mapped.")
+	check(t, !strings.Contains(cmap, "<81>"), "Undefined codes should be omitted.")
+}
+
+func TestDocWriter_toUnicode(t *testing.T) {
+	dw := NewDocWriter()
+	cmap1 := dw.toUnicode(codepage.Idx_CP1252)
+	cmap2 := dw.toUnicode(codepage.Idx_CP1252)
+	cmap3 := dw.toUnicode(codepage.Idx_ISO_8859_2)
+	check(t, cmap1 == cmap2, "ToUnicode CMaps should be shared for the same codepage.")
+	check(t, cmap1 != cmap3, "ToUnicode CMaps should differ between codepages.")
+}
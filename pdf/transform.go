@@ -0,0 +1,169 @@
+// Copyright 2015 Brent Rowland.
+// Use of this source code is governed the Apache License, Version 2.0, as described in the LICENSE file.
+
+package pdf
+
+import (
+	"errors"
+	"math"
+
+	"github.com/rowland/leadtype/font"
+)
+
+var errNoSavedState = errors.New("No saved state to restore.")
+
+var identityMatrix = Matrix{1, 0, 0, 1, 0, 0}
+
+// apply returns the point x, y transformed by m.
+func (m Matrix) apply(x, y float64) (float64, float64) {
+	return m.A*x + m.C*y + m.E, m.B*x + m.D*y + m.F
+}
+
+// concat returns the matrix that transforms a point by m, then by n.
+func (m Matrix) concat(n Matrix) Matrix {
+	return Matrix{
+		m.A*n.A + m.B*n.C, m.A*n.B + m.B*n.D,
+		m.C*n.A + m.D*n.C, m.C*n.B + m.D*n.D,
+		m.E*n.A + m.F*n.C + n.E, m.E*n.B + m.F*n.D + n.F,
+	}
+}
+
+// invert returns the inverse of m, or false if m is singular.
+func (m Matrix) invert() (Matrix, bool) {
+	det := m.A*m.D - m.B*m.C
+	if det == 0 {
+		return Matrix{}, false
+	}
+	return Matrix{
+		m.D / det, -m.B / det,
+		-m.C / det, m.A / det,
+		(m.C*m.F - m.D*m.E) / det, (m.B*m.E - m.A*m.F) / det,
+	}, true
+}
+
+// savedState records what SaveState saves for RestoreState.
+type savedState struct {
+	drawState drawState
+	last      drawState
+	fonts     []*font.Font
+	ctm       Matrix
+}
+
+// endContent ends any text object or path in progress, which the graphics state may not change within.
+func (pw *PageWriter) endContent() {
+	if pw.inText {
+		pw.endText()
+	}
+	pw.endGraph()
+}
+
+// Transform changes the coordinate system for what follows, so that x, y in the new system is
+// a*x + c*y + e, b*x + d*y + f in the current one. Coordinates are in the page's units, measured down from the top.
+// The current location stays where it is on the page, taking new coordinates.
+func (pw *PageWriter) Transform(a, b, c, d, e, f float64) {
+	pw.endContent()
+	h := pw.pageHeight
+	// Convert to the PDF coordinate system, in points with y increasing upward from the bottom of the page.
+	m := Matrix{a, 0 - b, 0 - c, d, c*h + pw.units.toPts(e), h - d*h - pw.units.toPts(f)}
+	pw.gw.concatMatrix(m.A, m.B, m.C, m.D, m.E, m.F)
+	if inv, ok := m.invert(); ok {
+		pw.loc.X, pw.loc.Y = inv.apply(pw.loc.X, pw.loc.Y)
+		pw.origin.X, pw.origin.Y = inv.apply(pw.origin.X, pw.origin.Y)
+	}
+	pw.ctm = m.concat(pw.ctm)
+}
+
+// Rotate turns the coordinate system counterclockwise by angle degrees about its origin.
+func (pw *PageWriter) Rotate(angle float64) {
+	sin, cos := math.Sincos(angle * math.Pi / 180)
+	pw.Transform(cos, -sin, sin, cos, 0, 0)
+}
+
+// Scale stretches the coordinate system by sx horizontally and sy vertically.
+func (pw *PageWriter) Scale(sx, sy float64) {
+	pw.Transform(sx, 0, 0, sy, 0, 0)
+}
+
+// Skew slants the x axis counterclockwise by xAngle degrees and the y axis clockwise by yAngle degrees.
+func (pw *PageWriter) Skew(xAngle, yAngle float64) {
+	pw.Transform(1, -math.Tan(xAngle*math.Pi/180), -math.Tan(yAngle*math.Pi/180), 1, 0, 0)
+}
+
+// Translate moves the origin of the coordinate system to x, y.
+func (pw *PageWriter) Translate(x, y float64) {
+	pw.Transform(1, 0, 0, 1, x, y)
+}
+
+// SaveState saves the graphics state, including the coordinate system, colours, line style and fonts,
+// to be restored by a matching call to RestoreState.
+func (pw *PageWriter) SaveState() {
+	pw.endContent()
+	pw.gw.saveGraphicsState()
+	pw.states = append(pw.states, savedState{
+		drawState: pw.drawState,
+		last:      pw.last,
+		fonts:     append([]*font.Font(nil), pw.fonts...),
+		ctm:       pw.ctm,
+	})
+}
+
+// RestoreState restores the graphics state saved by the most recent call to SaveState.
+// The current location stays where it is on the page, taking coordinates in the restored coordinate system.
+func (pw *PageWriter) RestoreState() error {
+	n := len(pw.states)
+	if n == 0 {
+		return errNoSavedState
+	}
+	pw.endContent()
+	pw.gw.restoreGraphicsState()
+	s := pw.states[n-1]
+	pw.states = pw.states[:n-1]
+	loc, origin := pw.loc, pw.origin
+	loc.X, loc.Y = pw.ctm.apply(loc.X, loc.Y)
+	origin.X, origin.Y = pw.ctm.apply(origin.X, origin.Y)
+	if inv, ok := s.ctm.invert(); ok {
+		loc.X, loc.Y = inv.apply(loc.X, loc.Y)
+		origin.X, origin.Y = inv.apply(origin.X, origin.Y)
+	}
+	pw.drawState = s.drawState
+	pw.last = s.last
+	pw.fonts = s.fonts
+	pw.ctm = s.ctm
+	pw.loc, pw.origin = loc, origin
+	return nil
+}
+
+// restoreStates restores any states still saved, balancing the content's save and restore operators.
+func (pw *PageWriter) restoreStates() {
+	for len(pw.states) > 0 {
+		pw.RestoreState()
+	}
+}
+
+func (dw *DocWriter) RestoreState() error {
+	return dw.CurPage().RestoreState()
+}
+
+func (dw *DocWriter) Rotate(angle float64) {
+	dw.CurPage().Rotate(angle)
+}
+
+func (dw *DocWriter) SaveState() {
+	dw.CurPage().SaveState()
+}
+
+func (dw *DocWriter) Scale(sx, sy float64) {
+	dw.CurPage().Scale(sx, sy)
+}
+
+func (dw *DocWriter) Skew(xAngle, yAngle float64) {
+	dw.CurPage().Skew(xAngle, yAngle)
+}
+
+func (dw *DocWriter) Transform(a, b, c, d, e, f float64) {
+	dw.CurPage().Transform(a, b, c, d, e, f)
+}
+
+func (dw *DocWriter) Translate(x, y float64) {
+	dw.CurPage().Translate(x, y)
+}
@@ -0,0 +1,72 @@
+// Copyright 2015 Brent Rowland.
+// Use of this source code is governed the Apache License, Version 2.0, as described in the LICENSE file.
+
+package pdf
+
+import (
+	"testing"
+
+	"github.com/rowland/leadtype/colors"
+	"github.com/rowland/leadtype/options"
+)
+
+func TestMatrix_invert(t *testing.T) {
+	m := Matrix{0, 1, -1, 0, 792, 792}
+	inv, ok := m.invert()
+	checkFatal(t, ok, "Rotation should be invertible.")
+	x, y := inv.apply(m.apply(10, 20))
+	expectF(t, 10, x)
+	expectF(t, 20, y)
+	check(t, m.concat(inv) == identityMatrix, "Matrix times inverse should be identity.")
+	_, ok = Matrix{1, 2, 2, 4, 0, 0}.invert()
+	check(t, !ok, "Singular matrix should not be invertible.")
+}
+
+func TestPageWriter_Rotate(t *testing.T) {
+	dw := NewDocWriter()
+	pw := newPageWriter(dw, options.Options{})
+	pw.Rotate(90)
+	expectS(t, "0 1 -1 0 792 792 cm\n", pw.stream.String())
+	pw.MoveTo(100, 0)
+	x, y := pw.ctm.apply(pw.loc.X, pw.loc.Y)
+	expectF(t, 0, x)
+	expectF(t, 892, y)
+}
+
+func TestPageWriter_SaveState(t *testing.T) {
+	dw := NewDocWriter()
+	pw := newPageWriter(dw, options.Options{})
+	pw.SetLineColor(colors.Red)
+	pw.MoveTo(100, 100)
+	pw.SaveState()
+	pw.Translate(72, 72)
+	expectF(t, 28, pw.X())
+	expectF(t, 28, pw.Y())
+	pw.SetLineColor(colors.Blue)
+	pw.SetLineWidth(2, "pt")
+	pw.MoveTo(0, 0)
+	pw.LineTo(72, 0)
+	check(t, pw.RestoreState() == nil, "RestoreState should succeed.")
+	expectS(t, "q\n1 0 0 1 72 -72 cm\n0 0 1 RG\n2 w\n0 792 m\n72 792 l\nS\nQ\n", pw.stream.String())
+	check(t, pw.LineColor() == colors.Red, "Line colour should be restored.")
+	expectF(t, 0, pw.LineWidth("pt"))
+	expectF(t, 144, pw.X())
+	expectF(t, 72, pw.Y())
+	pw.LineTo(144, 144)
+	expectS(t, "1 0 0 RG\n144 720 m\n144 648 l\n", pw.stream.String()[len("q\n1 0 0 1 72 -72 cm\n0 0 1 RG\n2 w\n0 792 m\n72 792 l\nS\nQ\n"):])
+	check(t, pw.RestoreState() == errNoSavedState, "RestoreState should fail without saved state.")
+}
+
+func TestPageWriter_Scale(t *testing.T) {
+	dw := NewDocWriter()
+	pw := newPageWriter(dw, options.Options{})
+	pw.MoveTo(100, 100)
+	pw.SaveState()
+	pw.Scale(2, 2)
+	expectS(t, "q\n2 0 0 2 0 -792 cm\n", pw.stream.String())
+	expectF(t, 50, pw.X())
+	expectF(t, 50, pw.Y())
+	pw.SaveState()
+	pw.close()
+	expectI(t, 0, len(pw.states))
+}
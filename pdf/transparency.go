@@ -0,0 +1,124 @@
+// Copyright 2015 Brent Rowland.
+// Use of this source code is governed the Apache License, Version 2.0, as described in the LICENSE file.
+
+package pdf
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+var errInvalidBlendMode = errors.New("Invalid blend mode.")
+
+var blendModes = map[string]bool{
+	"Normal": true, "Multiply": true, "Screen": true, "Overlay": true, "Darken": true, "Lighten": true,
+	"ColorDodge": true, "ColorBurn": true, "HardLight": true, "SoftLight": true, "Difference": true, "Exclusion": true,
+	"Hue": true, "Saturation": true, "Color": true, "Luminosity": true,
+}
+
+// extGStateKey identifies the combination of parameters set by an ExtGState resource.
+type extGStateKey struct {
+	fillOpacity, strokeOpacity float64
+	blendMode                  string
+}
+
+// extGState returns the name of the ExtGState resource setting fillOpacity, strokeOpacity and blendMode,
+// creating it on first use.
+func (dw *DocWriter) extGState(fillOpacity, strokeOpacity float64, blendMode string) string {
+	key := extGStateKey{fillOpacity, strokeOpacity, blendMode}
+	if name, ok := dw.extGStates[key]; ok {
+		return name
+	}
+	gs := newExtGState(dw.nextSeq(), 0, fillOpacity, strokeOpacity, blendMode)
+	dw.file.body.add(gs)
+	name := fmt.Sprintf("GS%d", len(dw.extGStates))
+	dw.resources.setExtGState(name, &indirectObjectRef{gs})
+	dw.extGStates[key] = name
+	dw.file.header.requireVersion(1.4)
+	return name
+}
+
+func clampOpacity(opacity float64) float64 {
+	return math.Max(0, math.Min(1, opacity))
+}
+
+// initTransparency sets opacity and blending to the defaults at the start of a page.
+func (pw *PageWriter) initTransparency() {
+	pw.fillOpacity, pw.strokeOpacity, pw.blendMode = 1, 1, "Normal"
+	pw.last.fillOpacity, pw.last.strokeOpacity, pw.last.blendMode = 1, 1, "Normal"
+}
+
+func (pw *PageWriter) checkSetExtGState() {
+	if pw.fillOpacity == pw.last.fillOpacity && pw.strokeOpacity == pw.last.strokeOpacity && pw.blendMode == pw.last.blendMode {
+		return
+	}
+	if pw.inPath && pw.autoPath {
+		pw.gw.stroke()
+		pw.inPath = false
+	}
+	pw.gw.setExtGState(pw.dw.extGState(pw.fillOpacity, pw.strokeOpacity, pw.blendMode))
+	pw.last.fillOpacity, pw.last.strokeOpacity, pw.last.blendMode = pw.fillOpacity, pw.strokeOpacity, pw.blendMode
+}
+
+func (pw *PageWriter) BlendMode() string {
+	return pw.blendMode
+}
+
+func (pw *PageWriter) FillOpacity() float64 {
+	return pw.fillOpacity
+}
+
+// SetBlendMode sets how what is painted is combined with what is beneath it: Normal, Multiply, Screen, Overlay,
+// Darken, Lighten, ColorDodge, ColorBurn, HardLight, SoftLight, Difference, Exclusion, Hue, Saturation, Color
+// or Luminosity.
+func (pw *PageWriter) SetBlendMode(mode string) (prev string, err error) {
+	prev = pw.blendMode
+	if !blendModes[mode] {
+		return prev, errInvalidBlendMode
+	}
+	pw.blendMode = mode
+	return
+}
+
+// SetFillOpacity sets the opacity, from 0 for transparent to 1 for opaque, of filled shapes, text and images.
+func (pw *PageWriter) SetFillOpacity(opacity float64) (prev float64) {
+	prev = pw.fillOpacity
+	pw.fillOpacity = clampOpacity(opacity)
+	return
+}
+
+// SetStrokeOpacity sets the opacity, from 0 for transparent to 1 for opaque, of lines and borders.
+func (pw *PageWriter) SetStrokeOpacity(opacity float64) (prev float64) {
+	prev = pw.strokeOpacity
+	pw.strokeOpacity = clampOpacity(opacity)
+	return
+}
+
+func (pw *PageWriter) StrokeOpacity() float64 {
+	return pw.strokeOpacity
+}
+
+func (dw *DocWriter) BlendMode() string {
+	return dw.CurPage().BlendMode()
+}
+
+func (dw *DocWriter) FillOpacity() float64 {
+	return dw.CurPage().FillOpacity()
+}
+
+func (dw *DocWriter) SetBlendMode(mode string) (prev string, err error) {
+	return dw.CurPage().SetBlendMode(mode)
+}
+
+func (dw *DocWriter) SetFillOpacity(opacity float64) (prev float64) {
+	return dw.CurPage().SetFillOpacity(opacity)
+}
+
+func (dw *DocWriter) SetStrokeOpacity(opacity float64) (prev float64) {
+	return dw.CurPage().SetStrokeOpacity(opacity)
+}
+
+func (dw *DocWriter) StrokeOpacity() float64 {
+	return dw.CurPage().StrokeOpacity()
+}
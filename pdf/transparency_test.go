@@ -0,0 +1,85 @@
+// Copyright 2015 Brent Rowland.
+// Use of this source code is governed the Apache License, Version 2.0, as described in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/rowland/leadtype/colors"
+	"github.com/rowland/leadtype/options"
+)
+
+func TestPageWriter_checkSetExtGState(t *testing.T) {
+	dw := NewDocWriter()
+	pw := newPageWriter(dw, options.Options{})
+	pw.checkSetExtGState()
+	expectS(t, "", pw.stream.String())
+
+	pw.SetFillOpacity(0.5)
+	pw.checkSetExtGState()
+	pw.checkSetExtGState()
+	expectS(t, "/GS0 gs\n", pw.stream.String())
+
+	pw.SetFillOpacity(1)
+	pw.checkSetExtGState()
+	pw.SetFillOpacity(0.5)
+	pw.checkSetExtGState()
+	expectS(t, "/GS0 gs\n/GS1 gs\n/GS0 gs\n", pw.stream.String())
+	expectI(t, 2, len(dw.extGStates))
+}
+
+func TestPageWriter_SetBlendMode(t *testing.T) {
+	dw := NewDocWriter()
+	pw := newPageWriter(dw, options.Options{})
+	expectS(t, "Normal", pw.BlendMode())
+	prev, err := pw.SetBlendMode("Multiply")
+	check(t, err == nil, "Multiply should be a valid blend mode.")
+	expectS(t, "Normal", prev)
+	prev, err = pw.SetBlendMode("Bogus")
+	check(t, err == errInvalidBlendMode, "Bogus should be an invalid blend mode.")
+	expectS(t, "Multiply", pw.BlendMode())
+}
+
+func TestPageWriter_SetFillOpacity(t *testing.T) {
+	dw := NewDocWriter()
+	pw := newPageWriter(dw, options.Options{})
+	expectF(t, 1, pw.FillOpacity())
+	expectF(t, 1, pw.SetFillOpacity(0.25))
+	expectF(t, 0.25, pw.SetFillOpacity(2))
+	expectF(t, 1, pw.FillOpacity())
+	pw.SetFillOpacity(-1)
+	expectF(t, 0, pw.FillOpacity())
+}
+
+func TestDocWriter_SetFillOpacity(t *testing.T) {
+	dw := NewDocWriter()
+	dw.SetCompressionLevel(NoCompression)
+	dw.NewPage()
+	dw.SetFillColor(colors.Yellow)
+	dw.SetFillOpacity(0.5)
+	dw.SetStrokeOpacity(0.25)
+	dw.SetBlendMode("Multiply")
+	dw.Rectangle(72, 72, 144, 36, true, true)
+	dw.SaveState()
+	dw.SetFillOpacity(1)
+	dw.Rectangle(72, 144, 144, 36, false, true)
+	dw.RestoreState()
+	dw.Rectangle(72, 216, 144, 36, false, true)
+	var buf bytes.Buffer
+	_, err := dw.WriteTo(&buf)
+	checkFatal(t, err == nil, "WriteTo should succeed.")
+	pdf := buf.String()
+	check(t, strings.HasPrefix(pdf, "%PDF-1.4\n"), "Transparency should require PDF 1.4.")
+	check(t, strings.Contains(pdf, "/Type /ExtGState "), "ExtGState should be written.")
+	check(t, strings.Contains(pdf, "/ca 0.5 "), "Fill opacity should be set.")
+	check(t, strings.Contains(pdf, "/CA 0.25 "), "Stroke opacity should be set.")
+	check(t, strings.Contains(pdf, "/BM /Multiply "), "Blend mode should be set.")
+	check(t, strings.Contains(pdf, "/ExtGState <<\n/GS0 "), "ExtGState should be in resources.")
+	check(t, strings.Contains(pdf, "/GS0 gs\n1 1 0 rg\n"), "Transparency should be set before colour.")
+	check(t, strings.Contains(pdf, "q\n/GS1 gs\n72 612 144 36 re\nf\nQ\n72 540 144 36 re\nf\n"),
+		"Transparency should be restored with graphics state.")
+	checkXRefTable(t, pdf)
+}
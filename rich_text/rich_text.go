@@ -24,7 +24,7 @@ type RichText struct {
 	Text               string
 	Font               *font.Font
 	FontSize           float64
-	Color              colors.Color
+	Color              colors.Paint
 	Underline          bool
 	Strikeout          bool
 	ascent             float64
@@ -40,6 +40,7 @@ type RichText struct {
 	CharSpacing        float64
 	WordSpacing        float64
 	NoBreak            bool
+	Link               string
 	pieces             []*RichText
 }
 
@@ -56,7 +57,7 @@ var errNoFontSet = errors.New("No font set")
 //
 // Options:
 //   color:        Fill text with color.
-//                 A value of type Color, a string with a color name from NamedColors map, or an RGB color as int, int32 or hexadecimal string.
+//                 A colors.Paint, a string parsed by colors.ParsePaint, or an RGB color as int or int32.
 //   underline:    Draw a line under text.
 //                 A bool, a string that evalutes to bool via strconv.ParseBool, a non-zero int or float64.
 //   strikeout:    Draw a line through text.
@@ -65,16 +66,18 @@ var errNoFontSet = errors.New("No font set")
 //   word_spacing: Add extra space between words, expressed in points.
 //   nobreak:      Prevent WordsToWidth or WrapToWidth from breaking within this stretch of text.
 //                 A bool, a string that evalutes to bool via strconv.ParseBool, a non-zero int or float64.
+//   link:         Make text a link to a URI, or to a named destination if prefixed with "#".
 func New(s string, fonts []*font.Font, fontSize float64, options options.Options) (*RichText, error) {
 	piece := &RichText{
 		Text:        s,
 		FontSize:    fontSize,
-		Color:       options.ColorDefault("color", colors.Black),
+		Color:       options.PaintDefault("color", colors.Black),
 		Underline:   options.BoolDefault("underline", false),
 		Strikeout:   options.BoolDefault("strikeout", false),
 		CharSpacing: options.FloatDefault("char_spacing", 0),
 		WordSpacing: options.FloatDefault("word_spacing", 0),
 		NoBreak:     options.BoolDefault("nobreak", false),
+		Link:        options.StringDefault("link", ""),
 	}
 	var defaultFont *font.Font
 	if len(fonts) == 0 {
@@ -379,7 +382,8 @@ func (piece *RichText) MatchesAttributes(other *RichText) bool {
 		piece.Underline == other.Underline &&
 		piece.Strikeout == other.Strikeout &&
 		piece.CharSpacing == other.CharSpacing &&
-		piece.WordSpacing == other.WordSpacing
+		piece.WordSpacing == other.WordSpacing &&
+		piece.Link == other.Link
 }
 
 func (piece *RichText) measure() *RichText {
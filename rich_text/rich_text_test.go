@@ -23,7 +23,7 @@ import (
 func TestNewRichText_English(t *testing.T) {
 	st := SuperTest{t}
 	fonts := ttf_fonts.Families("Arial")
-	rt, err := New("abc", fonts, 10, options.Options{"color": colors.Green, "underline": true, "strikeout": true, "nobreak": true})
+	rt, err := New("abc", fonts, 10, options.Options{"color": colors.Green, "underline": true, "strikeout": true, "nobreak": true, "link": "#abc"})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -33,6 +33,7 @@ func TestNewRichText_English(t *testing.T) {
 	st.True(rt.Underline)
 	st.True(rt.Strikeout)
 	st.True(rt.NoBreak)
+	st.Equal("#abc", rt.Link)
 	st.Equal(fonts[0], rt.Font, "Should be tagged with Arial font.")
 }
 
@@ -500,6 +501,10 @@ func TestRichText_MatchesAttributes(t *testing.T) {
 	p2 = p1
 	p2.WordSpacing = 1
 	st.False(p1.MatchesAttributes(&p2), "Attributes should not match.")
+
+	p2 = p1
+	p2.Link = "http://example.com/"
+	st.False(p1.MatchesAttributes(&p2), "Attributes should not match.")
 }
 
 func TestRichText_measure(t *testing.T) {
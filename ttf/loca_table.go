@@ -0,0 +1,41 @@
+// Copyright 2015 Brent Rowland.
+// Use of this source code is governed the Apache License, Version 2.0, as described in the LICENSE file.
+
+package ttf
+
+import (
+	"bufio"
+	"io"
+	"os"
+)
+
+type locaTable struct {
+	offsets []uint32
+}
+
+func (table *locaTable) init(rs io.ReadSeeker, entry *tableDirEntry, numGlyphs uint16, indexToLocFormat int16) (err error) {
+	if _, err = rs.Seek(int64(entry.offset), os.SEEK_SET); err != nil {
+		return
+	}
+	file := bufio.NewReaderSize(rs, int(entry.length))
+	table.offsets = make([]uint32, int(numGlyphs)+1)
+	if indexToLocFormat == 0 {
+		shortOffsets := make([]uint16, len(table.offsets))
+		if err = readValues(file, shortOffsets); err != nil {
+			return
+		}
+		for i, v := range shortOffsets {
+			table.offsets[i] = uint32(v) * 2
+		}
+		return
+	}
+	return readValues(file, table.offsets)
+}
+
+// glyphRange returns the start and end offsets of the glyph's outline within the glyf table.
+func (table *locaTable) glyphRange(glyphIndex int) (start, end uint32) {
+	if glyphIndex < 0 || glyphIndex+1 >= len(table.offsets) {
+		return 0, 0
+	}
+	return table.offsets[glyphIndex], table.offsets[glyphIndex+1]
+}
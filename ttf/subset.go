@@ -0,0 +1,273 @@
+// Copyright 2015 Brent Rowland.
+// Use of this source code is governed the Apache License, Version 2.0, as described in the LICENSE file.
+
+package ttf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// Tables retained in a subset font. These are the tables required to render TrueType outlines,
+// plus cmap, name, OS/2 and post, which some viewers consult for simple (non-CID) fonts.
+var subsetTables = []string{"OS/2", "cmap", "cvt ", "fpgm", "glyf", "head", "hhea", "hmtx", "loca", "maxp", "name", "post", "prep"}
+
+const (
+	compositeArg1And2AreWords   = 0x0001
+	compositeWeHaveAScale       = 0x0008
+	compositeMoreComponents     = 0x0020
+	compositeWeHaveAnXAndYScale = 0x0040
+	compositeWeHaveATwoByTwo    = 0x0080
+)
+
+// postHeaderLength is the length of the post table up to the glyph names of format 2.
+const postHeaderLength = 32
+
+var errNoGlyphOutlines = errors.New("Font has no glyf table to subset.")
+
+// GlyphIndex returns the index of the glyph mapped to codepoint by the font's preferred cmap, or -1 if none.
+func (font *Font) GlyphIndex(codepoint rune) int {
+	return font.cmapTable.glyphIndex(int(codepoint))
+}
+
+// Subset writes a copy of the font to wr containing only the outlines of the specified glyphs,
+// along with .notdef and any glyphs referenced by composites.
+// Glyph indexes are preserved so that the original cmap remains valid.
+// Glyphs following the highest glyph retained are dropped from loca and hmtx.
+func (font *Font) Subset(wr io.Writer, glyphs []int) (err error) {
+	var file *os.File
+	if file, err = os.Open(font.filename); err != nil {
+		return
+	}
+	defer file.Close()
+
+	tables := make(map[string][]byte, len(subsetTables))
+	for _, tag := range subsetTables {
+		if entry := font.tableDir.table(tag); entry != nil {
+			if tables[tag], err = readTable(file, entry); err != nil {
+				return
+			}
+		}
+	}
+	if tables["glyf"] == nil || font.tableDir.table("loca") == nil {
+		return errNoGlyphOutlines
+	}
+	var loca locaTable
+	if err = loca.init(file, font.tableDir.table("loca"), font.maxpTable.numGlyphs, font.headTable.indexToLocFormat); err != nil {
+		return
+	}
+
+	used := font.closeGlyphSet(glyphs, &loca, tables["glyf"])
+	numGlyphs := 1
+	for g := range used {
+		if g+1 > numGlyphs {
+			numGlyphs = g + 1
+		}
+	}
+
+	glyf, offsets := subsetGlyf(tables["glyf"], &loca, used, numGlyphs)
+	tables["glyf"] = glyf
+	tables["loca"], tables["head"] = subsetLoca(offsets, tables["head"])
+	tables["hmtx"], tables["hhea"] = subsetHmtx(tables["hmtx"], tables["hhea"], numGlyphs, int(font.hheaTable.numOfLongHorMetrics))
+	tables["maxp"] = append([]byte(nil), tables["maxp"]...)
+	binary.BigEndian.PutUint16(tables["maxp"][4:], uint16(numGlyphs))
+	if tables["post"] != nil {
+		tables["post"] = subsetPost(tables["post"])
+	}
+
+	return writeFontTables(wr, font.scalar, tables)
+}
+
+// closeGlyphSet returns the set of requested glyphs, plus .notdef and every component of composite glyphs.
+func (font *Font) closeGlyphSet(glyphs []int, loca *locaTable, glyf []byte) map[int]bool {
+	used := map[int]bool{0: true}
+	pending := append([]int(nil), glyphs...)
+	for len(pending) > 0 {
+		g := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if g < 0 || g >= int(font.maxpTable.numGlyphs) {
+			continue
+		}
+		if used[g] && g != 0 {
+			continue
+		}
+		used[g] = true
+		start, end := loca.glyphRange(g)
+		if end <= start || int(end) > len(glyf) {
+			continue
+		}
+		for _, component := range compositeComponents(glyf[start:end]) {
+			if !used[component] {
+				pending = append(pending, component)
+			}
+		}
+	}
+	return used
+}
+
+// compositeComponents returns the glyph indexes referenced by a composite glyph description.
+func compositeComponents(glyph []byte) (components []int) {
+	if len(glyph) < 10 || int16(binary.BigEndian.Uint16(glyph)) >= 0 {
+		return nil
+	}
+	for i := 10; i+4 <= len(glyph); {
+		flags := binary.BigEndian.Uint16(glyph[i:])
+		components = append(components, int(binary.BigEndian.Uint16(glyph[i+2:])))
+		i += 4
+		if flags&compositeArg1And2AreWords != 0 {
+			i += 4
+		} else {
+			i += 2
+		}
+		switch {
+		case flags&compositeWeHaveAScale != 0:
+			i += 2
+		case flags&compositeWeHaveAnXAndYScale != 0:
+			i += 4
+		case flags&compositeWeHaveATwoByTwo != 0:
+			i += 8
+		}
+		if flags&compositeMoreComponents == 0 {
+			break
+		}
+	}
+	return
+}
+
+func subsetGlyf(glyf []byte, loca *locaTable, used map[int]bool, numGlyphs int) ([]byte, []uint32) {
+	var buf bytes.Buffer
+	offsets := make([]uint32, numGlyphs+1)
+	for g := 0; g < numGlyphs; g++ {
+		offsets[g] = uint32(buf.Len())
+		if !used[g] {
+			continue
+		}
+		start, end := loca.glyphRange(g)
+		if end > start && int(end) <= len(glyf) {
+			buf.Write(glyf[start:end])
+			for buf.Len()%4 != 0 {
+				buf.WriteByte(0)
+			}
+		}
+	}
+	offsets[numGlyphs] = uint32(buf.Len())
+	return buf.Bytes(), offsets
+}
+
+// subsetLoca builds a loca table from offsets, using the short format when possible,
+// and returns it with a copy of the head table updated to match.
+func subsetLoca(offsets []uint32, head []byte) (loca []byte, newHead []byte) {
+	newHead = append([]byte(nil), head...)
+	if offsets[len(offsets)-1] <= 0x1FFFE {
+		loca = make([]byte, len(offsets)*2)
+		for i, v := range offsets {
+			binary.BigEndian.PutUint16(loca[i*2:], uint16(v/2))
+		}
+		binary.BigEndian.PutUint16(newHead[50:], 0)
+	} else {
+		loca = make([]byte, len(offsets)*4)
+		for i, v := range offsets {
+			binary.BigEndian.PutUint32(loca[i*4:], v)
+		}
+		binary.BigEndian.PutUint16(newHead[50:], 1)
+	}
+	return
+}
+
+// subsetHmtx truncates the hmtx table to numGlyphs entries and returns it with a copy of the hhea table updated to match.
+func subsetHmtx(hmtx, hhea []byte, numGlyphs, numOfLongHorMetrics int) (newHmtx, newHhea []byte) {
+	newHhea = append([]byte(nil), hhea...)
+	var length int
+	if numGlyphs <= numOfLongHorMetrics {
+		numOfLongHorMetrics = numGlyphs
+		length = numGlyphs * 4
+	} else {
+		length = numOfLongHorMetrics*4 + (numGlyphs-numOfLongHorMetrics)*2
+	}
+	if length < len(hmtx) {
+		hmtx = hmtx[:length]
+	}
+	newHmtx = hmtx
+	binary.BigEndian.PutUint16(newHhea[34:], uint16(numOfLongHorMetrics))
+	return
+}
+
+// subsetPost returns a format 3 copy of the post table, without glyph names,
+// as the names of a format 2 table would no longer match the number of glyphs.
+func subsetPost(post []byte) []byte {
+	if len(post) < postHeaderLength {
+		return post
+	}
+	newPost := append([]byte(nil), post[:postHeaderLength]...)
+	binary.BigEndian.PutUint32(newPost, 0x00030000)
+	return newPost
+}
+
+func readTable(rs io.ReadSeeker, entry *tableDirEntry) (data []byte, err error) {
+	if _, err = rs.Seek(int64(entry.offset), os.SEEK_SET); err != nil {
+		return
+	}
+	data = make([]byte, entry.length)
+	if _, err = io.ReadFull(rs, data); err != nil {
+		return nil, fmt.Errorf("Error reading %s table: %s", entry.tag, err)
+	}
+	return
+}
+
+func tableChecksum(data []byte) (sum uint32) {
+	for i := 0; i < len(data); i += 4 {
+		var word [4]byte
+		copy(word[:], data[i:])
+		sum += binary.BigEndian.Uint32(word[:])
+	}
+	return
+}
+
+func writeFontTables(wr io.Writer, scalar uint32, tables map[string][]byte) error {
+	tags := make([]string, 0, len(tables))
+	for tag := range tables {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	numTables := uint16(len(tags))
+	entrySelector := uint16(0)
+	for 1<<(entrySelector+1) <= numTables {
+		entrySelector++
+	}
+	searchRange := uint16(16 << entrySelector)
+	rangeShift := numTables*16 - searchRange
+
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.BigEndian, scalar)
+	binary.Write(&buf, binary.BigEndian, []uint16{numTables, searchRange, entrySelector, rangeShift})
+	offset := uint32(12 + 16*len(tags))
+	headOffset := uint32(0)
+	for _, tag := range tags {
+		data := tables[tag]
+		if tag == "head" {
+			headOffset = offset
+			binary.BigEndian.PutUint32(data[8:], 0)
+		}
+		buf.WriteString(tag)
+		binary.Write(&buf, binary.BigEndian, []uint32{tableChecksum(data), offset, uint32(len(data))})
+		offset += uint32(len(data)+3) &^ 3
+	}
+	for _, tag := range tags {
+		buf.Write(tables[tag])
+		for buf.Len()%4 != 0 {
+			buf.WriteByte(0)
+		}
+	}
+	font := buf.Bytes()
+	if tables["head"] != nil {
+		binary.BigEndian.PutUint32(font[headOffset+8:], 0xB1B0AFBA-tableChecksum(font))
+	}
+	_, err := wr.Write(font)
+	return err
+}
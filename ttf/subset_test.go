@@ -0,0 +1,70 @@
+// Copyright 2015 Brent Rowland.
+// Use of this source code is governed the Apache License, Version 2.0, as described in the LICENSE file.
+
+package ttf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestCompositeComponents(t *testing.T) {
+	simple := []byte{0, 1, 0, 0, 0, 0, 0, 0, 0, 0}
+	expectI(t, "simple", 0, len(compositeComponents(simple)))
+
+	composite := []byte{
+		0xFF, 0xFF, 0, 0, 0, 0, 0, 0, 0, 0, // numberOfContours, bbox
+		0x00, 0x21, 0x00, 0x24, 0x00, 0x00, 0x00, 0x00, // ARG_1_AND_2_ARE_WORDS | MORE_COMPONENTS, glyph 36
+		0x00, 0x08, 0x00, 0x70, 0x00, 0x00, 0x40, 0x00, // WE_HAVE_A_SCALE, glyph 112
+	}
+	components := compositeComponents(composite)
+	if len(components) != 2 {
+		t.Fatalf("expected 2 components, got %d", len(components))
+	}
+	expectI(t, "1st component", 36, components[0])
+	expectI(t, "2nd component", 112, components[1])
+}
+
+func TestSubsetPost(t *testing.T) {
+	post := make([]byte, postHeaderLength+6)
+	binary.BigEndian.PutUint32(post, 0x00020000)
+	binary.BigEndian.PutUint32(post[4:], 0xFFF48000) // italicAngle -11.5
+	binary.BigEndian.PutUint16(post[postHeaderLength:], 2)
+	newPost := subsetPost(post)
+	expectI(t, "length", postHeaderLength, len(newPost))
+	expectI(t, "format", 0x00030000, int(binary.BigEndian.Uint32(newPost)))
+	expectI(t, "italicAngle", 0xFFF48000, int(binary.BigEndian.Uint32(newPost[4:])))
+	expectI(t, "original format", 0x00020000, int(binary.BigEndian.Uint32(post)))
+}
+
+func TestFont_Subset(t *testing.T) {
+	f, err := LoadFont("/Library/Fonts/Arial.ttf")
+	if err != nil {
+		t.Fatalf("Error loading font: %s", err)
+	}
+	a, b := f.GlyphIndex('A'), f.GlyphIndex('b')
+	var buf bytes.Buffer
+	if err = f.Subset(&buf, []int{a, b}); err != nil {
+		t.Fatalf("Error subsetting font: %s", err)
+	}
+	tmp, err := ioutil.TempFile("", "subset")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(tmp.Name())
+	tmp.Write(buf.Bytes())
+	tmp.Close()
+
+	sf, err := LoadFont(tmp.Name())
+	if err != nil {
+		t.Fatalf("Error loading subset font: %s", err)
+	}
+	expect(t, "NumGlyphs", sf.NumGlyphs() > b && sf.NumGlyphs() < f.NumGlyphs())
+	expectI(t, "A", a, sf.GlyphIndex('A'))
+	expectI(t, "A width", aw(f.AdvanceWidth('A')), aw(sf.AdvanceWidth('A')))
+	expectI(t, "b width", aw(f.AdvanceWidth('b')), aw(sf.AdvanceWidth('b')))
+	expect(t, "subset size", buf.Len() < 100000)
+}